@@ -10,7 +10,7 @@
 #  - HIVE_BOOTNODE             enode URL of the remote bootstrap node
 #  - HIVE_NETWORK_ID           network ID number to use for the eth protocol
 #  - HIVE_CHAIN_ID             network ID number to use for the eth protocol
-#  - HIVE_NODETYPE             sync and pruning selector (archive, full, light)
+#  - HIVE_NODETYPE             sync and pruning selector (archive, full, fast, snap, light)
 #
 # Forks:
 #
@@ -126,10 +126,12 @@ fi
 
 # Sync mode.
 case "$HIVE_NODETYPE" in
-    "" | "full")
+    "" | "fast")
         FLAGS="$FLAGS --sync-mode=FAST --fast-sync-min-peers=1 --Xsynchronizer-fast-sync-pivot-distance=0" ;;
-    "light")
-        echo "Ignoring HIVE_NODETYPE == light: besu does not support light client" ;;
+    "archive" | "full")
+        FLAGS="$FLAGS --sync-mode=FULL" ;;
+    "snap" | "light")
+        echo "Ignoring HIVE_NODETYPE == $HIVE_NODETYPE: besu does not support this sync mode" ;;
 esac
 
 # Configure RPC.
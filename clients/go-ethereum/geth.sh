@@ -14,7 +14,12 @@
 #  - HIVE_BOOTNODE                enode URL of the remote bootstrap node
 #  - HIVE_NETWORK_ID              network ID number to use for the eth protocol
 #  - HIVE_TESTNET                 whether testnet nonces (2^20) are needed
-#  - HIVE_NODETYPE                sync and pruning selector (archive, full, light)
+#  - HIVE_NODETYPE                sync and pruning selector (archive, full, fast, snap, light)
+#  - HIVE_LES_SERVER              if set, the node serves light clients
+#  - HIVE_DISCV5                  if set, the node also runs Node Discovery v5
+#  - HIVE_DNS_DISCOVERY           enrtree:// URL of a node list for DNS discovery (EIP-1459)
+#  - HIVE_DNS_RESOLVER            IP address of the DNS server used for DNS discovery
+#  - HIVE_TXPOOL_SIZE             maximum number of transactions in the transaction pool
 #
 # Forks:
 #
@@ -76,43 +81,50 @@ if [ "$HIVE_TESTNET" == "1" ]; then
 fi
 
 # Handle any client mode or operation requests
-if [ "$HIVE_NODETYPE" == "full" ]; then
-	FLAGS="$FLAGS --syncmode fast "
-fi
-if [ "$HIVE_NODETYPE" == "light" ]; then
-	FLAGS="$FLAGS --syncmode light "
-fi
-
-# Configure the chain.
-mv /genesis.json /genesis-input.json
-jq -f /mapper.jq /genesis-input.json > /genesis.json
-
-# Dump genesis
-echo "Supplied genesis state:"
-cat /genesis.json
-
-# Initialize the local testchain with the genesis state
-echo "Initializing database with genesis state..."
-$geth $FLAGS init /genesis.json
-
-# Don't immediately abort, some imports are meant to fail
-set +e
+case "$HIVE_NODETYPE" in
+    "archive")
+        FLAGS="$FLAGS --syncmode full --gcmode archive" ;;
+    "full" | "fast" | "snap" | "light")
+        FLAGS="$FLAGS --syncmode $HIVE_NODETYPE" ;;
+esac
+
+# init_chain initializes the database with /genesis.json and imports the test chain.
+# It is also run after a reset requested by /reset.sh.
+init_chain() {
+    # Configure the chain.
+    mv /genesis.json /genesis-input.json
+    jq -f /mapper.jq /genesis-input.json > /genesis.json
+
+    # Dump genesis
+    echo "Supplied genesis state:"
+    cat /genesis.json
+
+    # Initialize the local testchain with the genesis state
+    echo "Initializing database with genesis state..."
+    $geth $CHAIN_FLAGS init /genesis.json
+
+    # Don't immediately abort, some imports are meant to fail
+    set +e
+
+    # Load the test chain if present
+    echo "Loading initial blockchain..."
+    if [ -f /chain.rlp ]; then
+    	$geth $CHAIN_FLAGS --gcmode=archive import /chain.rlp
+    else
+    	echo "Warning: chain.rlp not found."
+    fi
 
-# Load the test chain if present
-echo "Loading initial blockchain..."
-if [ -f /chain.rlp ]; then
-	$geth $FLAGS --gcmode=archive import /chain.rlp
-else
-	echo "Warning: chain.rlp not found."
-fi
+    # Load the remainder of the test chain
+    echo "Loading remaining individual blocks..."
+    if [ -d /blocks ]; then
+    	(cd /blocks && $geth $CHAIN_FLAGS --gcmode=archive --verbosity=$HIVE_LOGLEVEL --nocompaction import `ls | sort -n`)
+    else
+    	echo "Warning: blocks folder not found."
+    fi
+}
 
-# Load the remainder of the test chain
-echo "Loading remaining individual blocks..."
-if [ -d /blocks ]; then
-	(cd /blocks && $geth $FLAGS --gcmode=archive --verbosity=$HIVE_LOGLEVEL --nocompaction import `ls | sort -n`)
-else
-	echo "Warning: blocks folder not found."
-fi
+CHAIN_FLAGS="$FLAGS"
+init_chain
 
 set -e
 
@@ -138,6 +150,30 @@ if [ "$HIVE_MINER_EXTRA" != "" ]; then
 fi
 FLAGS="$FLAGS --miner.gasprice 16000000000"
 
+# Serve light clients.
+if [ "$HIVE_LES_SERVER" != "" ]; then
+	FLAGS="$FLAGS --light.serve 100 --light.nosyncserve"
+fi
+
+# Run discovery v5 alongside v4.
+if [ "$HIVE_DISCV5" != "" ]; then
+	FLAGS="$FLAGS --v5disc"
+fi
+
+# Find peers using DNS discovery. The node list is served by a test-specific DNS server.
+if [ "$HIVE_DNS_RESOLVER" != "" ]; then
+	echo "nameserver $HIVE_DNS_RESOLVER" > /etc/resolv.conf
+fi
+if [ "$HIVE_DNS_DISCOVERY" != "" ]; then
+	FLAGS="$FLAGS --discovery.dns=$HIVE_DNS_DISCOVERY"
+fi
+
+# Limit the transaction pool. The capacity of the geth pool is the sum of the global
+# slot and queue limits, and the queue limit must be at least one.
+if [ "$HIVE_TXPOOL_SIZE" != "" ]; then
+	FLAGS="$FLAGS --txpool.globalslots=$((HIVE_TXPOOL_SIZE - 1)) --txpool.globalqueue=1"
+fi
+
 # Configure RPC.
 FLAGS="$FLAGS --http --http.addr=0.0.0.0 --http.port=8545 --http.api=admin,debug,eth,miner,net,personal,txpool,web3"
 FLAGS="$FLAGS --ws --ws.addr=0.0.0.0 --ws.origins \"*\" --ws.api=admin,debug,eth,miner,net,personal,txpool,web3"
@@ -149,7 +185,18 @@ if [ "$HIVE_ALLOW_UNPROTECTED_TX" != "" ]; then
  	FLAGS="$FLAGS --rpc.allow-unprotected-txs"
 fi
 
-# Run the go-ethereum implementation with the requested flags.
+# Run the go-ethereum implementation with the requested flags. When /reset.sh
+# stops geth, the database is wiped and the chain reinitialized before restarting.
 FLAGS="$FLAGS --nat=none"
-echo "Running go-ethereum with flags $FLAGS"
-$geth $FLAGS
+set +e
+while true; do
+    echo "Running go-ethereum with flags $FLAGS"
+    $geth $FLAGS
+    if [ ! -f /reset-requested ]; then
+        break
+    fi
+    echo "Resetting chain..."
+    rm -rf /root/.ethereum/geth/chaindata /root/.ethereum/geth/lightchaindata
+    init_chain
+    rm -f /reset-requested
+done
@@ -0,0 +1,18 @@
+#!/bin/bash
+
+# Script to reset the client to a new chain.
+#
+# This is run by simulators which reuse the client instance across tests. Before
+# running it, the simulator replaces `/genesis.json` and the `/blocks` folder. The
+# script stops geth, and geth.sh then reinitializes the database from these files
+# and restarts the client. The script returns when the chain has been imported.
+
+# Immediately abort the script on any error encountered
+set -e
+
+touch /reset-requested
+pkill -INT -x geth
+
+while [ -f /reset-requested ]; do
+    sleep 0.1
+done
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/ecdsa"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Clique header field sizes.
+const (
+	cliqueExtraVanity = 32
+	cliqueExtraSeal   = crypto.SignatureLength
+)
+
+// setupClique configures the genesis block for a clique chain with a single signer.
+func (cfg *generatorConfig) setupClique(key *ecdsa.PrivateKey, period uint64) {
+	cfg.cliqueKey = key
+	cfg.cliquePeriod = period
+
+	config := *cfg.genesis.Config
+	config.Ethash = nil
+	config.Clique = &params.CliqueConfig{Period: period, Epoch: 30000}
+	cfg.genesis.Config = &config
+
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	extra := make([]byte, cliqueExtraVanity+common.AddressLength+cliqueExtraSeal)
+	copy(extra[cliqueExtraVanity:], signer[:])
+	cfg.genesis.ExtraData = extra
+}
+
+// cliqueSeal wraps the clique engine, signing blocks as they are produced. It only
+// supports chains with a single signer, whose blocks are all in-turn.
+type cliqueSeal struct {
+	consensus.Engine
+	key *ecdsa.PrivateKey
+}
+
+// FinalizeAndAssemble implements consensus.Engine, setting the clique header fields and
+// signing the block.
+func (e cliqueSeal) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	// Make room for the signature, keeping any vanity set by the block generator.
+	vanity := header.Extra
+	header.Extra = make([]byte, cliqueExtraVanity+cliqueExtraSeal)
+	copy(header.Extra[:cliqueExtraVanity], vanity)
+	header.Coinbase = common.Address{}
+
+	block, err := e.Engine.FinalizeAndAssemble(chain, header, state, txs, uncles, receipts)
+	if err != nil {
+		return nil, err
+	}
+	sealed := block.Header()
+	sig, err := crypto.Sign(clique.SealHash(sealed).Bytes(), e.key)
+	if err != nil {
+		return nil, err
+	}
+	copy(sealed.Extra[len(sealed.Extra)-cliqueExtraSeal:], sig)
+	return block.WithSeal(sealed), nil
+}
+
+// isClique reports whether the genesis block is configured for clique.
+func isClique(gspec *core.Genesis) bool {
+	return gspec.Config != nil && gspec.Config.Clique != nil
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// loadBlocks reads all blocks in a chain.rlp file.
+func loadBlocks(file string) ([]*types.Block, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var blocks []*types.Block
+	s := rlp.NewStream(bufio.NewReader(fd), 0)
+	for i := 0; ; i++ {
+		var block types.Block
+		err := s.Decode(&block)
+		if err == io.EOF {
+			return blocks, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("block %d: %v", i, err)
+		}
+		blocks = append(blocks, &block)
+	}
+}
+
+// invalidBlockError is returned by importChain when a block fails validation.
+type invalidBlockError struct {
+	block *types.Block
+	err   error
+}
+
+func (e *invalidBlockError) Error() string {
+	return fmt.Sprintf("invalid block %d (%x): %v", e.block.Number(), e.block.Hash(), e.err)
+}
+
+// importChain processes the given blocks on top of the genesis block. When the chain
+// contains an invalid block, the error is an *invalidBlockError.
+func importChain(gspec *core.Genesis, blocks []*types.Block, engine consensus.Engine) (*core.BlockChain, error) {
+	db := rawdb.NewMemoryDatabase()
+	if _, err := gspec.Commit(db); err != nil {
+		return nil, fmt.Errorf("invalid genesis: %v", err)
+	}
+	blockchain, err := newBlockChain(db, gspec, engine)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := blockchain.InsertChain(blocks); err != nil {
+		blockchain.Stop()
+		if n < len(blocks) {
+			return nil, &invalidBlockError{blocks[n], err}
+		}
+		return nil, err
+	}
+	return blockchain, nil
+}
+
+// blockTest is the JSON format of a test in the BlockchainTests of the ethereum/tests
+// repository.
+type blockTest struct {
+	Blocks     []btBlock             `json:"blocks"`
+	Genesis    *btHeader             `json:"genesisBlockHeader"`
+	GenesisRLP hexutil.Bytes         `json:"genesisRLP"`
+	Pre        core.GenesisAlloc     `json:"pre"`
+	Post       core.GenesisAlloc     `json:"postState"`
+	BestBlock  common.UnprefixedHash `json:"lastblockhash"`
+	Network    string                `json:"network"`
+	SealEngine string                `json:"sealEngine"`
+}
+
+type btBlock struct {
+	BlockHeader  *btHeader     `json:"blockHeader"`
+	Rlp          hexutil.Bytes `json:"rlp"`
+	UncleHeaders []*btHeader   `json:"uncleHeaders"`
+}
+
+type btHeader struct {
+	Bloom            types.Bloom           `json:"bloom"`
+	Coinbase         common.Address        `json:"coinbase"`
+	MixHash          common.Hash           `json:"mixHash"`
+	Nonce            types.BlockNonce      `json:"nonce"`
+	Number           *math.HexOrDecimal256 `json:"number"`
+	Hash             common.Hash           `json:"hash"`
+	ParentHash       common.Hash           `json:"parentHash"`
+	ReceiptTrie      common.Hash           `json:"receiptTrie"`
+	StateRoot        common.Hash           `json:"stateRoot"`
+	TransactionsTrie common.Hash           `json:"transactionsTrie"`
+	UncleHash        common.Hash           `json:"uncleHash"`
+	ExtraData        hexutil.Bytes         `json:"extraData"`
+	Difficulty       *math.HexOrDecimal256 `json:"difficulty"`
+	GasLimit         math.HexOrDecimal64   `json:"gasLimit"`
+	GasUsed          math.HexOrDecimal64   `json:"gasUsed"`
+	Timestamp        math.HexOrDecimal64   `json:"timestamp"`
+	BaseFee          *math.HexOrDecimal256 `json:"baseFeePerGas,omitempty"`
+}
+
+func newBTHeader(h *types.Header) *btHeader {
+	return &btHeader{
+		Bloom:            h.Bloom,
+		Coinbase:         h.Coinbase,
+		MixHash:          h.MixDigest,
+		Nonce:            h.Nonce,
+		Number:           (*math.HexOrDecimal256)(h.Number),
+		Hash:             h.Hash(),
+		ParentHash:       h.ParentHash,
+		ReceiptTrie:      h.ReceiptHash,
+		StateRoot:        h.Root,
+		TransactionsTrie: h.TxHash,
+		UncleHash:        h.UncleHash,
+		ExtraData:        h.Extra,
+		Difficulty:       (*math.HexOrDecimal256)(h.Difficulty),
+		GasLimit:         math.HexOrDecimal64(h.GasLimit),
+		GasUsed:          math.HexOrDecimal64(h.GasUsed),
+		Timestamp:        math.HexOrDecimal64(h.Time),
+		BaseFee:          (*math.HexOrDecimal256)(h.BaseFee),
+	}
+}
+
+// exportBlockTest writes the chain as a blockchain test named name.
+func exportBlockTest(file, name string, gspec *core.Genesis, blockchain *core.BlockChain, sealEngine string) error {
+	head := blockchain.CurrentBlock()
+	network, err := networkName(gspec.Config, head.NumberU64())
+	if err != nil {
+		return err
+	}
+	genesis := blockchain.Genesis()
+	genesisRLP, _ := rlp.EncodeToBytes(genesis)
+	headstate, err := blockchain.State()
+	if err != nil {
+		return err
+	}
+	post, err := dumpAlloc(headstate)
+	if err != nil {
+		return err
+	}
+	test := &blockTest{
+		Genesis:    newBTHeader(genesis.Header()),
+		GenesisRLP: genesisRLP,
+		Pre:        gspec.Alloc,
+		Post:       post,
+		BestBlock:  common.UnprefixedHash(head.Hash()),
+		Network:    network,
+		SealEngine: sealEngine,
+	}
+	for n := uint64(1); n <= head.NumberU64(); n++ {
+		block := blockchain.GetBlockByNumber(n)
+		enc, _ := rlp.EncodeToBytes(block)
+		btb := btBlock{BlockHeader: newBTHeader(block.Header()), Rlp: enc, UncleHeaders: []*btHeader{}}
+		for _, uncle := range block.Uncles() {
+			btb.UncleHeaders = append(btb.UncleHeaders, newBTHeader(uncle))
+		}
+		test.Blocks = append(test.Blocks, btb)
+	}
+
+	js, err := json.MarshalIndent(map[string]*blockTest{name: test}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, js, 0644)
+}
+
+// dumpAlloc returns all accounts in the given state.
+func dumpAlloc(statedb *state.StateDB) (core.GenesisAlloc, error) {
+	dump := statedb.RawDump(&state.DumpConfig{OnlyWithAddresses: true})
+	alloc := make(core.GenesisAlloc, len(dump.Accounts))
+	for addr, acc := range dump.Accounts {
+		balance, ok := new(big.Int).SetString(acc.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %q of account %x", acc.Balance, addr)
+		}
+		account := core.GenesisAccount{
+			Balance: balance,
+			Nonce:   acc.Nonce,
+			Code:    acc.Code,
+		}
+		if len(acc.Storage) > 0 {
+			account.Storage = make(map[common.Hash]common.Hash, len(acc.Storage))
+			for key, value := range acc.Storage {
+				account.Storage[key] = common.HexToHash(value)
+			}
+		}
+		alloc[addr] = account
+	}
+	return alloc, nil
+}
+
+// forks is the list of forks in activation order, named as in blockchain tests.
+var forks = []struct {
+	name  string
+	block func(*params.ChainConfig) *big.Int
+}{
+	{"Homestead", func(c *params.ChainConfig) *big.Int { return c.HomesteadBlock }},
+	{"EIP150", func(c *params.ChainConfig) *big.Int { return c.EIP150Block }},
+	{"EIP158", func(c *params.ChainConfig) *big.Int { return c.EIP158Block }},
+	{"Byzantium", func(c *params.ChainConfig) *big.Int { return c.ByzantiumBlock }},
+	{"Constantinople", func(c *params.ChainConfig) *big.Int { return c.ConstantinopleBlock }},
+	{"ConstantinopleFix", func(c *params.ChainConfig) *big.Int { return c.PetersburgBlock }},
+	{"Istanbul", func(c *params.ChainConfig) *big.Int { return c.IstanbulBlock }},
+	{"Berlin", func(c *params.ChainConfig) *big.Int { return c.BerlinBlock }},
+	{"London", func(c *params.ChainConfig) *big.Int { return c.LondonBlock }},
+}
+
+// networkName returns the blockchain test network name of a chain configuration. Only
+// configurations with all forks at genesis, or a single transition at block 5, have a
+// network name.
+func networkName(config *params.ChainConfig, head uint64) (string, error) {
+	var (
+		name       = "Frontier"
+		transition string
+	)
+	for _, f := range forks {
+		block := f.block(config)
+		switch {
+		case block == nil || block.Uint64() > head:
+			continue
+		case block.Sign() == 0 && transition == "":
+			name = f.name
+		case block.Uint64() == 5:
+			transition = f.name
+		default:
+			return "", fmt.Errorf("chain config has no blockchain test network name (%s at block %v)", f.name, block)
+		}
+	}
+	if transition != "" {
+		return fmt.Sprintf("%sTo%sAt5", name, transition), nil
+	}
+	return name, nil
+}
+
+// exportBlocksJSON writes the blocks of the chain in JSON-RPC format to dir, one file
+// per block.
+func exportBlocksJSON(dir string, blockchain *core.BlockChain) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	head := blockchain.CurrentBlock().NumberU64()
+	for n := uint64(0); n <= head; n++ {
+		block := blockchain.GetBlockByNumber(n)
+		td := blockchain.GetTd(block.Hash(), n)
+		fields, err := rpcMarshalBlock(block, td, blockchain.Config())
+		if err != nil {
+			return fmt.Errorf("block %d: %v", n, err)
+		}
+		js, _ := json.MarshalIndent(fields, "", "  ")
+		file := filepath.Join(dir, fmt.Sprintf("%04d.json", n))
+		if err := ioutil.WriteFile(file, js, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rpcMarshalBlock converts a block to the format returned by eth_getBlockByNumber with
+// full transactions.
+func rpcMarshalBlock(block *types.Block, td *big.Int, config *params.ChainConfig) (map[string]interface{}, error) {
+	fields, err := toJSONMap(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	fields["size"] = hexutil.Uint64(block.Size())
+	fields["totalDifficulty"] = (*hexutil.Big)(td)
+
+	uncles := make([]common.Hash, 0, len(block.Uncles()))
+	for _, uncle := range block.Uncles() {
+		uncles = append(uncles, uncle.Hash())
+	}
+	fields["uncles"] = uncles
+
+	signer := types.MakeSigner(config, block.Number())
+	txs := make([]interface{}, 0, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		txfields, err := toJSONMap(tx)
+		if err != nil {
+			return nil, err
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			return nil, fmt.Errorf("tx %d: %v", i, err)
+		}
+		// Legacy transactions have no fee cap fields.
+		for _, key := range []string{"maxFeePerGas", "maxPriorityFeePerGas"} {
+			if txfields[key] == nil {
+				delete(txfields, key)
+			}
+		}
+		txfields["from"] = from
+		txfields["blockHash"] = block.Hash()
+		txfields["blockNumber"] = (*hexutil.Big)(block.Number())
+		txfields["transactionIndex"] = hexutil.Uint64(i)
+		txs = append(txs, txfields)
+	}
+	fields["transactions"] = txs
+	return fields, nil
+}
+
+// toJSONMap encodes v as a JSON object.
+func toJSONMap(v interface{}) (map[string]interface{}, error) {
+	enc, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(enc, &fields)
+	return fields, err
+}
@@ -1,26 +1,35 @@
 package main
 
 import (
+	"bytes"
 	"crypto/ecdsa"
-	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"math/big"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 func init() {
@@ -41,15 +50,28 @@ var (
 	genstorage    = hexutil.MustDecode("0x60015b8080556001015a6161a81063000000025700")
 	genlogs       = hexutil.MustDecode("0x4360005260006020525b63000000156300000027565b60206020a15a61271010630000000957005b60205160010160205260406000209056")
 	gencode       = hexutil.MustDecode("0x630000001960010138038063000000196001016000396000f35b")
+	genprecomp    = hexutil.MustDecode("0x60015b604060c060c0600060008562030d40f15060c05181556001018060091163000000025700")
+
+	// These are the coinbases of uncle blocks and blocks of the sibling chain.
+	uncleCoinbase = common.HexToAddress("0x00000000000000000000000000000000000000c1")
+	forkCoinbase  = common.HexToAddress("0x00000000000000000000000000000000000000f1")
 )
 
 type generatorConfig struct {
-	txInterval   int // frequency of blocks containing transactions
-	txCount      int // number of txs in block
-	blockCount   int // number of generated blocks
-	blockTimeSec int // block time in seconds, influences difficulty
-	powMode      ethash.Mode
-	genesis      core.Genesis
+	txInterval    int // frequency of blocks containing transactions
+	txCount       int // number of txs in block
+	blockCount    int // number of generated blocks
+	blockTimeSec  int // block time in seconds, influences difficulty
+	uncleInterval int // frequency of blocks containing an uncle
+	forkAt        int // number of the block after which the sibling chain diverges
+	powMode       ethash.Mode
+	genesis       core.Genesis
+	seed          int64 // seed of random transaction contents, 0 = derived from genesis
+	rng           *rand.Rand
+
+	// clique settings, used when cliqueKey is set
+	cliqueKey    *ecdsa.PrivateKey
+	cliquePeriod uint64
 }
 
 // loadGenesis loads genesis.json.
@@ -59,16 +81,38 @@ func loadGenesis(file string) (*core.Genesis, error) {
 	return &gspec, err
 }
 
-// writeTestChain creates a test chain with no transactions or other
-// modifications based on an externally specified genesis file. The blockTimeInSeconds is
-// used to manipulate the block difficulty.
+// writeTestChain creates a test chain based on an externally specified genesis file.
+// The blockTimeInSeconds is used to manipulate the block difficulty.
 func (cfg generatorConfig) writeTestChain(outputPath string) error {
+	cfg.rng = rand.New(rand.NewSource(cfg.randomSeed()))
+	engine := cfg.newEngine()
 	blockModifier := func(i int, gen *core.BlockGen) {
 		log.Println("generating block", gen.Number())
-		gen.OffsetTime(int64((i+1)*int(cfg.blockTimeSec) - 10))
+		if cfg.cliqueKey != nil {
+			gen.OffsetTime(int64(cfg.cliquePeriod) - 10)
+			// The clique engine can't compute the difficulty during generation.
+			// With a single signer, all blocks are in-turn.
+			gen.SetDifficulty(big.NewInt(2))
+			// Clique pays fees to the signer. The coinbase is cleared when sealing.
+			gen.SetCoinbase(crypto.PubkeyToAddress(cfg.cliqueKey.PublicKey))
+		} else {
+			gen.OffsetTime(int64((i+1)*int(cfg.blockTimeSec) - 10))
+		}
+		cfg.addUncle(i, gen, engine)
 		cfg.addTxForKnownAccounts(i, gen)
 	}
-	return cfg.generateAndSave(outputPath, blockModifier)
+	return cfg.generateAndSave(outputPath, engine, blockModifier)
+}
+
+// randomSeed returns the seed of the generator's randomness. Unless set on the command
+// line, it is derived from the genesis block, so the same genesis always yields the same
+// chain.
+func (cfg generatorConfig) randomSeed() int64 {
+	if cfg.seed != 0 {
+		return cfg.seed
+	}
+	hash := cfg.genesis.ToBlock(nil).Hash()
+	return int64(binary.BigEndian.Uint64(hash[:8]))
 }
 
 const (
@@ -76,41 +120,91 @@ const (
 	txTypeStorage
 	txTypeLogs
 	txTypeCode
+	txTypePrecompile
 	txTypeMax
 )
 
+// addUncle adds an uncle to the generated block if the block number matches the uncle
+// interval. The uncle is a sibling of the parent block.
+func (cfg generatorConfig) addUncle(i int, gen *core.BlockGen, engine consensus.Engine) {
+	n := int(gen.Number().Uint64()) - 1
+	if cfg.uncleInterval == 0 || i == 0 || n%cfg.uncleInterval != 0 {
+		return
+	}
+
+	var (
+		config  = cfg.genesis.Config
+		parent  = gen.PrevBlock(i - 2)
+		sibling = gen.PrevBlock(i - 1)
+	)
+	uncle := &types.Header{
+		ParentHash:  parent.Hash(),
+		UncleHash:   types.EmptyUncleHash,
+		Coinbase:    uncleCoinbase,
+		Root:        parent.Root(),
+		TxHash:      types.EmptyRootHash,
+		ReceiptHash: types.EmptyRootHash,
+		Difficulty:  ethash.CalcDifficulty(config, sibling.Time(), parent.Header()),
+		Number:      sibling.Number(),
+		GasLimit:    sibling.GasLimit(),
+		Time:        sibling.Time(),
+		Extra:       sibling.Extra(),
+	}
+	if config.IsLondon(uncle.Number) {
+		uncle.BaseFee = misc.CalcBaseFee(config, parent.Header())
+	}
+	sealed, err := sealHeader(engine, uncle)
+	if err != nil {
+		panic(err)
+	}
+	log.Printf("adding uncle %v in block %d", sealed.Hash(), gen.Number())
+	gen.AddUncle(sealed)
+}
+
 // addTxForKnownAccounts adds a transaction to the generated chain if the genesis block
 // contains certain known accounts.
 func (cfg generatorConfig) addTxForKnownAccounts(i int, gen *core.BlockGen) {
-	if cfg.txInterval == 0 || i%cfg.txInterval != 0 {
+	n := int(gen.Number().Uint64()) - 1
+	if cfg.txInterval == 0 || n%cfg.txInterval != 0 {
 		return
 	}
 
-	txType := (i / cfg.txInterval) % txTypeMax
+	var (
+		txType    = (n / cfg.txInterval) % txTypeMax
+		envelopes = txEnvelopes(cfg.genesis.Config, gen.Number())
+		envelope  = envelopes[(n/cfg.txInterval)%len(envelopes)]
+	)
 
-	var gasLimit uint64
-	if gen.Number().Uint64() > 0 {
-		prev := gen.PrevBlock(-1)
-		gasLimit = core.CalcGasLimit(prev.GasUsed(), prev.GasLimit(), 0, cfg.genesis.GasLimit)
+	// The block gas limit is equal to the parent's, except in the London
+	// transition block.
+	prev := gen.PrevBlock(i - 1)
+	gasLimit := prev.GasLimit()
+	if cfg.genesis.Config.IsLondon(gen.Number()) && !cfg.genesis.Config.IsLondon(prev.Number()) {
+		gasLimit *= params.ElasticityMultiplier
 	}
 
 	var (
 		txGasSum uint64
 		txCount  = 0
-		accounts = make(map[common.Address]*ecdsa.PrivateKey)
+		accounts []common.Address
 	)
-	for addr, key := range knownAccounts {
+	for addr := range knownAccounts {
 		if _, ok := cfg.genesis.Alloc[addr]; ok {
-			accounts[addr] = key
+			accounts = append(accounts, addr)
 		}
 	}
+	// Accounts send in a fixed order to keep the chain deterministic.
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i][:], accounts[j][:]) < 0
+	})
 
 	for txCount <= cfg.txCount && len(accounts) > 0 {
-		for addr, key := range accounts {
-			tx := generateTx(txType, key, &cfg.genesis, gen)
+		for j := 0; j < len(accounts); {
+			addr := accounts[j]
+			tx := generateTx(cfg.rng, txType, envelope, knownAccounts[addr], &cfg.genesis, gen)
 			// Check if account has enough balance left to cover the tx.
 			if gen.GetBalance(addr).Cmp(tx.Cost()) < 0 {
-				delete(accounts, addr)
+				accounts = append(accounts[:j], accounts[j+1:]...)
 				continue
 			}
 			// Check if block gas limit reached.
@@ -118,90 +212,186 @@ func (cfg generatorConfig) addTxForKnownAccounts(i int, gen *core.BlockGen) {
 				return
 			}
 
-			log.Printf("adding tx (type %d) from %s in block %d", txType, addr.String(), gen.Number())
+			log.Printf("adding tx (type %d, envelope %d) from %s in block %d", txType, envelope, addr.String(), gen.Number())
 			log.Printf("%v (%d gas)", tx.Hash(), tx.Gas())
 			gen.AddTx(tx)
 			txGasSum += tx.Gas()
 			txCount++
+			j++
 		}
 	}
 }
 
-// generateTx creates a random transaction signed by the given account.
-func generateTx(txType int, key *ecdsa.PrivateKey, genesis *core.Genesis, gen *core.BlockGen) *types.Transaction {
+// txEnvelopes returns the transaction types which are valid in the given block.
+func txEnvelopes(config *params.ChainConfig, num *big.Int) []byte {
+	switch {
+	case config.IsLondon(num):
+		return []byte{types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType}
+	case config.IsBerlin(num):
+		return []byte{types.LegacyTxType, types.AccessListTxType}
+	default:
+		return []byte{types.LegacyTxType}
+	}
+}
+
+// generateTx creates a random transaction signed by the given account. Random contents
+// are drawn from rng.
+func generateTx(rng *rand.Rand, txType int, envelope byte, key *ecdsa.PrivateKey, genesis *core.Genesis, gen *core.BlockGen) *types.Transaction {
 	var (
 		src      = crypto.PubkeyToAddress(key.PublicKey)
-		gasprice = big.NewInt(0)
-		tx       *types.Transaction
+		nonce    = gen.TxNonce(src)
+		to       *common.Address
+		value    = new(big.Int)
+		data     []byte
+		extraGas uint64
 	)
 	// Generate according to type.
 	switch txType {
 	case txTypeValue:
-		amount := big.NewInt(1)
 		var dst common.Address
-		rand.Read(dst[:])
-		tx = types.NewTransaction(gen.TxNonce(src), dst, amount, params.TxGas, gasprice, nil)
+		rng.Read(dst[:])
+		to, value = &dst, big.NewInt(1)
 	case txTypeStorage:
-		gas := createTxGasLimit(gen, genesis, genstorage) + 80000
-		tx = types.NewContractCreation(gen.TxNonce(src), new(big.Int), gas, gasprice, genstorage)
+		data, extraGas = genstorage, 80000
 	case txTypeLogs:
-		gas := createTxGasLimit(gen, genesis, genlogs) + 20000
-		tx = types.NewContractCreation(gen.TxNonce(src), new(big.Int), gas, gasprice, genlogs)
+		data, extraGas = genlogs, 20000
 	case txTypeCode:
 		// The code generator contract deploys any data given after its own bytecode.
 		codesize := 128
-		input := make([]byte, len(gencode)+codesize)
-		copy(input, gencode)
-		rand.Read(input[len(gencode):])
-		extraGas := 10000 + params.CreateDataGas*uint64(codesize)
-		gas := createTxGasLimit(gen, genesis, gencode) + extraGas
-		tx = types.NewContractCreation(gen.TxNonce(src), new(big.Int), gas, gasprice, input)
+		data = make([]byte, len(gencode)+codesize)
+		copy(data, gencode)
+		rng.Read(data[len(gencode):])
+		extraGas = 10000 + params.CreateDataGas*uint64(codesize)
+	case txTypePrecompile:
+		data, extraGas = genprecomp, 500000
+	}
+
+	// Typed transactions access the recipient or the created contract.
+	var accessList types.AccessList
+	if envelope != types.LegacyTxType {
+		if to != nil {
+			accessList = types.AccessList{{Address: *to}}
+		} else {
+			contract := crypto.CreateAddress(src, nonce)
+			accessList = types.AccessList{{Address: contract, StorageKeys: []common.Hash{{}}}}
+		}
+	}
+	gas := txGasLimit(gen, genesis, data, accessList, to == nil) + extraGas
+
+	// Since London, the fee must cover the base fee.
+	gasprice := new(big.Int)
+	if genesis.Config.IsLondon(gen.Number()) {
+		gasprice = gen.BaseFee()
+	}
+	var txdata types.TxData
+	switch envelope {
+	case types.LegacyTxType:
+		txdata = &types.LegacyTx{Nonce: nonce, GasPrice: gasprice, Gas: gas, To: to, Value: value, Data: data}
+	case types.AccessListTxType:
+		txdata = &types.AccessListTx{
+			ChainID:    genesis.Config.ChainID,
+			Nonce:      nonce,
+			GasPrice:   gasprice,
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}
+	case types.DynamicFeeTxType:
+		tip := big.NewInt(params.GWei)
+		txdata = &types.DynamicFeeTx{
+			ChainID:    genesis.Config.ChainID,
+			Nonce:      nonce,
+			GasTipCap:  tip,
+			GasFeeCap:  new(big.Int).Add(gasprice, tip),
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}
 	}
 	// Sign the transaction.
 	signer := types.MakeSigner(genesis.Config, gen.Number())
-	signedTx, err := types.SignTx(tx, signer, key)
+	signedTx, err := types.SignNewTx(key, signer, txdata)
 	if err != nil {
 		panic(err)
 	}
 	return signedTx
 }
 
-func createTxGasLimit(gen *core.BlockGen, genesis *core.Genesis, data []byte) uint64 {
+func txGasLimit(gen *core.BlockGen, genesis *core.Genesis, data []byte, accessList types.AccessList, isCreate bool) uint64 {
 	isHomestead := genesis.Config.IsHomestead(gen.Number())
 	isEIP2028 := genesis.Config.IsIstanbul(gen.Number())
-	igas, err := core.IntrinsicGas(data, nil, true, isHomestead, isEIP2028)
+	igas, err := core.IntrinsicGas(data, accessList, isCreate, isHomestead, isEIP2028)
 	if err != nil {
 		panic(err)
 	}
 	return igas
 }
 
-// generateAndSave produces a chain based on the config.
-func (cfg generatorConfig) generateAndSave(path string, blockModifier func(i int, gen *core.BlockGen)) error {
-	db := rawdb.NewMemoryDatabase()
-	genesis := cfg.genesis.MustCommit(db)
+// newEngine creates the consensus engine used for chain generation.
+func (cfg generatorConfig) newEngine() consensus.Engine {
+	if cfg.cliqueKey != nil {
+		return cliqueSeal{clique.New(cfg.genesis.Config.Clique, rawdb.NewMemoryDatabase()), cfg.cliqueKey}
+	}
+	return instaSeal{newEthash(cfg.powMode)}
+}
+
+// newEthash creates an ethash engine in the given mode.
+func newEthash(mode ethash.Mode) *ethash.Ethash {
 	config := ethash.Config{
-		PowMode:        cfg.powMode,
+		PowMode:        mode,
 		CachesInMem:    2,
 		DatasetsOnDisk: 2,
 		DatasetDir:     ethashDir(),
 	}
-	engine := ethash.New(config, nil, false)
+	return ethash.New(config, nil, false)
+}
+
+// newBlockChain creates a blockchain for importing blocks on top of the genesis block,
+// which must already be committed to db.
+func newBlockChain(db ethdb.Database, gspec *core.Genesis, engine consensus.Engine) (*core.BlockChain, error) {
+	// Preimages are recorded so the post-state dump contains account addresses.
+	cacheConfig := &core.CacheConfig{
+		TrieCleanLimit: 256,
+		TrieDirtyLimit: 256,
+		TrieTimeLimit:  5 * time.Minute,
+		Preimages:      true,
+	}
+	blockchain, err := core.NewBlockChain(db, cacheConfig, gspec.Config, engine, vm.Config{}, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't create blockchain: %v", err)
+	}
+	// error out if blockchain config is nil -- avoid hanging chain generation
+	if blockchain.Config() == nil {
+		blockchain.Stop()
+		return nil, fmt.Errorf("cannot insert chain with nil chain config")
+	}
+	return blockchain, nil
+}
+
+// generateAndSave produces a chain based on the config.
+func (cfg generatorConfig) generateAndSave(path string, engine consensus.Engine, blockModifier func(i int, gen *core.BlockGen)) error {
+	if cfg.forkAt < 0 || cfg.forkAt >= cfg.blockCount {
+		return fmt.Errorf("fork block %d out of range", cfg.forkAt)
+	}
+	if cfg.cliqueKey != nil && cfg.uncleInterval != 0 {
+		return fmt.Errorf("clique chains can't contain uncles")
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := cfg.genesis.MustCommit(db)
 
 	// Generate a chain where each block is created, modified, and immediately sealed.
-	insta := instaSeal{engine}
-	chain, _ := core.GenerateChain(cfg.genesis.Config, genesis, insta, db, cfg.blockCount, blockModifier)
+	chain, _ := core.GenerateChain(cfg.genesis.Config, genesis, engine, db, cfg.blockCount, blockModifier)
 
 	// Import the chain. This runs all block validation rules.
-	blockchain, err := core.NewBlockChain(db, nil, cfg.genesis.Config, engine, vm.Config{}, nil, nil)
+	blockchain, err := newBlockChain(db, &cfg.genesis, engine)
 	if err != nil {
-		return fmt.Errorf("can't create blockchain: %v", err)
+		return err
 	}
 	defer blockchain.Stop()
-	// error out if blockchain config is nil -- avoid hanging chain generation
-	if blockchain.Config() == nil {
-		return fmt.Errorf("cannot insert chain with nil chain config")
-	}
 	if _, err := blockchain.InsertChain(chain); err != nil {
 		return fmt.Errorf("chain validation error: %v", err)
 	}
@@ -218,7 +408,37 @@ func (cfg generatorConfig) generateAndSave(path string, blockModifier func(i int
 	if err := ioutil.WriteFile(filepath.Join(path, "chain_poststate.json"), dump, 0644); err != nil {
 		return err
 	}
-	return nil
+	// The genesis block of clique chains contains the signer, so it is written as well.
+	if cfg.cliqueKey != nil {
+		genesisJSON, err := json.MarshalIndent(&cfg.genesis, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(path, "genesis.json"), genesisJSON, 0644); err != nil {
+			return err
+		}
+	}
+	if cfg.forkAt == 0 {
+		return nil
+	}
+
+	// Generate the sibling chain, which shares the first forkAt blocks.
+	forkModifier := func(i int, gen *core.BlockGen) {
+		if cfg.cliqueKey != nil {
+			// Clique blocks have no coinbase, the extra-data vanity marks sibling blocks.
+			gen.SetExtra([]byte("hivechain fork"))
+		} else {
+			gen.SetCoinbase(forkCoinbase)
+		}
+		blockModifier(i, gen)
+	}
+	forkBase := chain[cfg.forkAt-1]
+	fork, _ := core.GenerateChain(cfg.genesis.Config, forkBase, engine, db, cfg.blockCount-cfg.forkAt, forkModifier)
+	if _, err := blockchain.InsertChain(fork); err != nil {
+		return fmt.Errorf("fork validation error: %v", err)
+	}
+	sibling := append(chain[:cfg.forkAt:cfg.forkAt], fork...)
+	return writeBlocks(filepath.Join(path, "chain_fork.rlp"), sibling)
 }
 
 // ethashDir returns the directory for storing ethash datasets.
@@ -240,10 +460,34 @@ func writeChain(chain *core.BlockChain, filename string, start uint64) error {
 	return chain.ExportN(out, start, chain.CurrentBlock().NumberU64())
 }
 
+// writeBlocks writes the given blocks to a file.
+func writeBlocks(filename string, blocks []*types.Block) error {
+	out, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	for _, block := range blocks {
+		if err := rlp.Encode(out, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // instaSeal wraps a consensus engine with instant block sealing. When a block is produced
 // using FinalizeAndAssemble, it also applies Seal.
 type instaSeal struct{ consensus.Engine }
 
+// sealHeader seals a header which isn't part of the chain, e.g. an uncle.
+func sealHeader(engine consensus.Engine, header *types.Header) (*types.Header, error) {
+	sealedBlock := make(chan *types.Block, 1)
+	if err := engine.Seal(nil, types.NewBlockWithHeader(header), sealedBlock, nil); err != nil {
+		return nil, err
+	}
+	return (<-sealedBlock).Header(), nil
+}
+
 // FinalizeAndAssemble implements consensus.Engine, accumulating the block and uncle rewards,
 // setting the final state and assembling the block.
 func (e instaSeal) FinalizeAndAssemble(chain consensus.ChainHeaderReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
@@ -4,6 +4,15 @@
 //
 //     hivechain generate -length 10 -genesis ./genesis.json -blocktime 30 -output .
 //
+// With -fork-at, it also writes a competing chain to chain_fork.rlp:
+//
+//     hivechain generate -length 10 -genesis ./genesis.json -fork-at 5 -uncle-interval 3
+//
+// With -consensus clique, blocks are signed by the given key. The genesis.json written to
+// the output directory contains the signer:
+//
+//     hivechain generate -length 10 -genesis ./genesis.json -consensus clique -clique.key <hex>
+//
 // The 'print' subcommand displays blocks in a chain.rlp file:
 //
 //     hivechain print -v chain.rlp
@@ -16,6 +25,16 @@
 //
 //     hivechain trim -from 10 -to 100 chain.rlp newchain.rlp
 //
+// The 'export' subcommand converts a chain.rlp file to a blockchain test, or to JSON
+// files containing the blocks as returned by eth_getBlockByNumber:
+//
+//     hivechain export -genesis genesis.json -output test.json chain.rlp
+//     hivechain export -genesis genesis.json -format blocks -output ./blocks chain.rlp
+//
+// The 'verify' subcommand imports a chain.rlp file and reports the first invalid block:
+//
+//     hivechain verify -genesis genesis.json chain.rlp
+//
 package main
 
 import (
@@ -26,13 +45,18 @@ import (
 	"io"
 	"os"
 
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	ethlog "github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
-const usage = "Usage: hivechain generate|print|print-genesis|trim [ options ] ..."
+const usage = "Usage: hivechain generate|print|print-genesis|trim|export|verify [ options ] ..."
 
 func main() {
 	// Initialize go-ethereum logging.
@@ -52,6 +76,10 @@ func main() {
 		printGenesisCommand(os.Args[2:])
 	case "trim":
 		trimCommand(os.Args[2:])
+	case "export":
+		exportCommand(os.Args[2:])
+	case "verify":
+		verifyCommand(os.Args[2:])
 	default:
 		fatalf(usage)
 	}
@@ -153,6 +181,93 @@ func trimCommand(args []string) {
 	fmt.Println(written, "blocks written to", flag.Arg(1))
 }
 
+// exportCommand converts a chain.rlp file to other formats.
+func exportCommand(args []string) {
+	var (
+		genesis = flag.String("genesis", "", "The path and filename to the genesis.json of the chain")
+		format  = flag.String("format", "blocktest", "Output format (blocktest, blocks)")
+		output  = flag.String("output", "", "Output file (blocktest) or directory (blocks)")
+		name    = flag.String("name", "hivechain", "Name of the blockchain test")
+		pow     = flag.Bool("pow", false, "If set, proof-of-work seals are verified")
+	)
+	flag.CommandLine.Parse(args)
+	if flag.NArg() != 1 {
+		fatalf("Usage: hivechain export [ options ] <chain.rlp>")
+	}
+	if *genesis == "" || *output == "" {
+		fatalf("Missing -genesis or -output option.")
+	}
+
+	gspec, blockchain := loadAndImport(*genesis, flag.Arg(0), *pow)
+	defer blockchain.Stop()
+
+	var err error
+	switch *format {
+	case "blocktest":
+		if isClique(gspec) {
+			fatalf("Clique chains can't be exported as blockchain tests.")
+		}
+		sealEngine := "NoProof"
+		if *pow {
+			sealEngine = "Ethash"
+		}
+		err = exportBlockTest(*output, *name, gspec, blockchain, sealEngine)
+	case "blocks":
+		err = exportBlocksJSON(*output, blockchain)
+	default:
+		fatalf("Unknown export format %q", *format)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// verifyCommand imports a chain.rlp file, checking all blocks.
+func verifyCommand(args []string) {
+	var (
+		genesis = flag.String("genesis", "", "The path and filename to the genesis.json of the chain")
+		pow     = flag.Bool("pow", false, "If set, proof-of-work seals are verified")
+	)
+	flag.CommandLine.Parse(args)
+	if flag.NArg() != 1 {
+		fatalf("Usage: hivechain verify [ options ] <chain.rlp>")
+	}
+	if *genesis == "" {
+		fatalf("Missing -genesis option, please supply a genesis.json file.")
+	}
+
+	_, blockchain := loadAndImport(*genesis, flag.Arg(0), *pow)
+	defer blockchain.Stop()
+	head := blockchain.CurrentBlock()
+	fmt.Printf("chain is valid, head %d (%x)\n", head.Number(), head.Hash())
+}
+
+// loadAndImport processes the blocks of a chain.rlp file on top of a genesis block.
+func loadAndImport(genesisFile, chainFile string, pow bool) (*core.Genesis, *core.BlockChain) {
+	gspec, err := loadGenesis(genesisFile)
+	if err != nil {
+		fatal(err)
+	}
+	blocks, err := loadBlocks(chainFile)
+	if err != nil {
+		fatal(err)
+	}
+	var engine consensus.Engine
+	switch {
+	case isClique(gspec):
+		engine = clique.New(gspec.Config.Clique, rawdb.NewMemoryDatabase())
+	case pow:
+		engine = newEthash(ethash.ModeNormal)
+	default:
+		engine = newEthash(ethash.ModeFullFake)
+	}
+	blockchain, err := importChain(gspec, blocks, engine)
+	if err != nil {
+		fatal(err)
+	}
+	return gspec, blockchain
+}
+
 // generateCommand generates a test chain.
 func generateCommand(args []string) {
 	var (
@@ -160,11 +275,17 @@ func generateCommand(args []string) {
 		genesis = flag.String("genesis", "", "The path and filename to the source genesis.json")
 		outdir  = flag.String("output", ".", "Chain destination folder")
 		mine    = flag.Bool("mine", false, "Enables ethash mining")
+		engine  = flag.String("consensus", "ethash", "Consensus engine (ethash, clique)")
+		key     = flag.String("clique.key", "", "Hex private key of the clique signer")
+		period  = flag.Uint64("clique.period", 1, "Clique block period in seconds")
 	)
 	flag.IntVar(&cfg.blockCount, "length", 2, "The length of the chain to generate")
 	flag.IntVar(&cfg.blockTimeSec, "blocktime", 30, "The desired block time in seconds")
 	flag.IntVar(&cfg.txInterval, "tx-interval", 10, "Add transactions to chain every n blocks")
 	flag.IntVar(&cfg.txCount, "tx-count", 1, "Maximum number of txs per block")
+	flag.IntVar(&cfg.uncleInterval, "uncle-interval", 0, "Add an uncle to the chain every n blocks (0 = no uncles)")
+	flag.IntVar(&cfg.forkAt, "fork-at", 0, "Also generate a sibling chain diverging after block n (0 = no sibling chain)")
+	flag.Int64Var(&cfg.seed, "seed", 0, "Seed for random transaction contents (0 = derive from genesis)")
 	flag.CommandLine.Parse(args)
 
 	if *genesis == "" {
@@ -181,6 +302,27 @@ func generateCommand(args []string) {
 	}
 	cfg.genesis = *gspec
 
+	switch *engine {
+	case "ethash":
+	case "clique":
+		if *mine {
+			fatalf("-mine can't be used with clique")
+		}
+		if *key == "" {
+			fatalf("Missing -clique.key option.")
+		}
+		if *period == 0 {
+			fatalf("-clique.period must be at least one second")
+		}
+		signer, err := crypto.HexToECDSA(*key)
+		if err != nil {
+			fatalf("Invalid -clique.key: %v", err)
+		}
+		cfg.setupClique(signer, *period)
+	default:
+		fatalf("Unknown consensus engine %q", *engine)
+	}
+
 	if err := cfg.writeTestChain(*outdir); err != nil {
 		fatal(err)
 	}
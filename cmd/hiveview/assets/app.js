@@ -387,10 +387,180 @@ function formatTestDetails(d) {
         txt += utils.urls_to_links(utils.html_encode(d.summaryResult.details));
         txt += "</code></pre></p>";
     }
+    txt += formatResourceUsage(d.clientInfo);
+    txt += formatClientMetrics(d.clientInfo);
     txt += "</div>";
     return txt;
 }
 
+// These are the charted values of client resource usage.
+const resourceMetrics = [
+    {key: "cpuPercent", title: "CPU", format: function(v) { return v.toFixed(1) + "%"; }},
+    {key: "memoryRSS", title: "Memory RSS", format: formatBytes},
+    {key: "netRx", title: "Net RX", format: formatByteRate},
+    {key: "netTx", title: "Net TX", format: formatByteRate},
+    {key: "blockRead", title: "Disk read", format: formatByteRate},
+    {key: "blockWrite", title: "Disk write", format: formatByteRate},
+];
+
+function formatBytes(v) {
+    const units = ["B", "KiB", "MiB", "GiB", "TiB"];
+    let i = 0;
+    while (v >= 1024 && i < units.length - 1) {
+        v /= 1024;
+        i++;
+    }
+    return v.toFixed(i == 0 ? 0 : 1) + " " + units[i];
+}
+
+function formatByteRate(v) {
+    return formatBytes(v) + "/s";
+}
+
+// formatResourceUsage renders a chart of resource usage for each client of a test.
+// Bars show average (dark) and peak (light) values. Each metric is scaled
+// to the highest peak among the clients, so clients can be compared.
+function formatResourceUsage(clientInfo) {
+    let clients = [];
+    for (let id in clientInfo) {
+        if (clientInfo[id].resources) {
+            clients.push(clientInfo[id]);
+        }
+    }
+    if (clients.length == 0) {
+        return "";
+    }
+    let scale = {};
+    for (let m of resourceMetrics) {
+        scale[m.key] = 0;
+        for (let c of clients) {
+            scale[m.key] = Math.max(scale[m.key], c.resources[m.key].peak);
+        }
+    }
+
+    const width = 200, height = 12;
+    let txt = "<p><b>Resource usage</b></p>";
+    for (let c of clients) {
+        txt += "<div>" + utils.html_encode(c.name) + " (" + utils.html_encode(c.id) + ")";
+        txt += "<table class=\"resource-chart\">";
+        for (let m of resourceMetrics) {
+            let stat = c.resources[m.key];
+            let max = scale[m.key] || 1;
+            let avgW = Math.round(width * stat.avg / max);
+            let peakW = Math.round(width * stat.peak / max);
+            txt += "<tr><td>" + m.title + "</td><td>";
+            txt += '<svg width="' + width + '" height="' + height + '">';
+            txt += '<rect width="' + peakW + '" height="' + height + '" fill="#9ecae1"></rect>';
+            txt += '<rect width="' + avgW + '" height="' + height + '" fill="#3182bd"></rect>';
+            txt += "</svg></td>";
+            txt += "<td>avg " + m.format(stat.avg) + ", peak " + m.format(stat.peak) + "</td></tr>";
+        }
+        txt += "</table></div>";
+    }
+    return txt;
+}
+
+// formatClientMetrics renders links to load the metrics scraped from clients of a test.
+function formatClientMetrics(clientInfo) {
+    let txt = "";
+    for (let id in clientInfo) {
+        let c = clientInfo[id];
+        if (!c.metricsFile) {
+            continue;
+        }
+        let elemID = "client-metrics-" + c.id;
+        let js = "showClientMetrics(" + JSON.stringify(elemID) + "," + JSON.stringify(c.metricsFile) + ")";
+        txt += '<div id="' + utils.attr_encode(elemID) + '">';
+        txt += utils.get_js_link(js, "Show metrics of " + c.name + " (" + c.id + ")");
+        txt += "</div>";
+    }
+    if (txt == "") {
+        return "";
+    }
+    return "<p><b>Client metrics</b></p>" + txt;
+}
+
+// These series are plotted by default when they are present.
+const defaultMetricSeries = [
+    /peers?$/i,
+    /head_slot$/i,
+    /(chain_head_block|block_?number|block_?height)$/i,
+];
+
+// showClientMetrics loads a client metrics file and plots series of it into
+// the element with the given ID.
+function showClientMetrics(elemID, metricsFile) {
+    let elem = $(document.getElementById(elemID));
+    elem.text("Loading metrics...");
+    $.get(resultsRoot + metricsFile, function(data) {
+        let samples = [];
+        for (let line of data.split("\n")) {
+            if (line.trim() != "") {
+                samples.push(JSON.parse(line));
+            }
+        }
+        let names = new Set();
+        for (let s of samples) {
+            for (let name in s.samples) {
+                names.add(name);
+            }
+        }
+        names = Array.from(names).sort();
+        if (names.length == 0) {
+            elem.text("No metrics were recorded.");
+            return;
+        }
+
+        let select = $("<select>").append($("<option>").text("Add series..."));
+        for (let name of names) {
+            select.append($("<option>").text(name));
+        }
+        let charts = $("<div>");
+        select.on("change", function() {
+            if (select[0].selectedIndex > 0) {
+                charts.append(metricsChart(samples, select.val()));
+                select[0].selectedIndex = 0;
+            }
+        });
+        elem.empty().append(select, charts);
+
+        for (let re of defaultMetricSeries) {
+            let name = names.find(function(n) { return re.test(n); });
+            if (name) {
+                charts.append(metricsChart(samples, name));
+            }
+        }
+    }, "text").fail(function() {
+        elem.text("Metrics of this client are not available.");
+    });
+}
+
+// metricsChart renders a line chart of a series as SVG.
+function metricsChart(samples, name) {
+    const width = 600, height = 120, pad = 4;
+    let points = [];
+    for (let s of samples) {
+        if (name in s.samples) {
+            points.push({t: Date.parse(s.time), v: s.samples[name]});
+        }
+    }
+    let t0 = points[0].t, t1 = points[points.length - 1].t;
+    let vmin = Math.min.apply(null, points.map(function(p) { return p.v; }));
+    let vmax = Math.max.apply(null, points.map(function(p) { return p.v; }));
+    let coords = points.map(function(p) {
+        let x = pad + (t1 > t0 ? (p.t - t0) / (t1 - t0) : 0) * (width - 2 * pad);
+        let y = height - pad - (vmax > vmin ? (p.v - vmin) / (vmax - vmin) : 0.5) * (height - 2 * pad);
+        return x.toFixed(1) + "," + y.toFixed(1);
+    });
+
+    let txt = "<div><p>" + utils.html_encode(name);
+    txt += " (min " + vmin + ", max " + vmax + ", " + Math.round((t1 - t0) / 1000) + "s)</p>";
+    txt += '<svg width="' + width + '" height="' + height + '" style="border: 1px solid #ddd">';
+    txt += '<polyline fill="none" stroke="#3182bd" stroke-width="1.5" points="' + coords.join(" ") + '"></polyline>';
+    txt += "</svg></div>";
+    return txt;
+}
+
 function onSuiteData(data, jsonsource) {
     // data structure of suite data:
     /*
@@ -495,7 +665,11 @@ function onSuiteData(data, jsonsource) {
                     let logs = []
                     for (let instanceID in clientInfo) {
                         let instanceInfo = clientInfo[instanceID]
-                        logs.push(logview("results/" + instanceInfo.logFile, instanceInfo.name))
+                        let link = logview("results/" + instanceInfo.logFile, instanceInfo.name)
+                        if (instanceInfo.pcapFile) {
+                            link += " (" + utils.get_link("results/" + instanceInfo.pcapFile, "pcap") + ")"
+                        }
+                        logs.push(link)
                     }
                     return logs.join(",")
                 },
@@ -249,100 +249,140 @@ BiRfLf4bAAD//6qI6yuoEQAA
 	"/app.js": {
 		name:    "app.js",
 		local:   "assets/app.js",
-		size:    17090,
-		modtime: 1625438776,
+		size:    23671,
+		modtime: 1792336841,
 		compressed: `
-H4sIAAAAAAAC/7Q7f3fbuJH/+1PMstmIjCVScpw4li2723hzTZvd7Euy13e1/FKIhCTYFMACoGR34/vs
-9wYAf4pynHYvf8QSMBjMDAbzE4oFVxokVXmq1QchNEzAi9z3yNvbyzVLFUzgtz0AgOiZ+QPP4M+ffno3
-oDwWCeMLNxiZv0u9Sj+bGTqGec5jzQT3lZaBQ2IQRe+oBr2kcPH+J0gEMA1zISFXYQmzJhISmEAi4nxF
-uQ5jSYmmP6YUv/k9TW81kZT0gpNyTRIyzqn8RG+RE6XlSW3L/6GqD297KyAbIimIeW0Ollpn4yhSmsQ3
-Yk3lPBWbMBar6J85VciCikYHo+NXL4cRcljyPmB8cE3WRMWSZXpw/c+cyrs64rdwnSsNieA9DWQhKa1Y
-lFTnkhdUo0wtvff9vT23nPKYZCpPiaaQEE2AccUSCgQ0sYLXZFETtL7L+vD7S1vfZTvF7Hse7Js9T7Y5
-E7nu5KyhSfCD1pLNck2hU6eI1vKrOnW7m3rv1qvRdhsqqssdfW/m9VvUozBImsIEbtsM1JgjaRqqfKa0
-ZHzhv+ybgZTyhV7CAF4Guzi+YFLfQS7TQUakYnwBYm5OJ5cpLIlagqILpLshgwXVn3Hyc0YkWamaHOpC
-QMol1WuCxP923xg3qgkT2DCeiE2Yipjg+hCx1hgZtQSxJhItgFkdqixl2u89rV86VCUfIRlMYHgCDE7N
-IieLE2D7+3UaC8QZYRImBvSSXRWoJ3XUTtprkl4mFI//1w9vX4tVJjgeKyK4HF4FV3jyO6ZHV0GJ7b59
-ghb1rnN6bXRIAeFAeLwUckCtPsFcihX0cq5lrjRNepAyfgM9vJ+9rUPDudpp5TLtA9qu9rGRB2xdw8iR
-lv4uJZ17fdSeBpC2txP/bKtux70s6L1WbZKv1f8PxV5lOMdoQq7Vf8RAYTI/0CwlMVXw64d3ChgHxrNc
-w4bppbU2yJ4zfblM1WctDMfqIX8Fn5aE36h+4ShymUq6oLfGR5RwKUVvChOIfN8/HyPspbo6H0+jaRT4
-5+PL6eBk/HQy3Z8+6U83V/t/DM4vfxj8nQz+NRwcT8Pp4Gr/i38+3mw20/DLNnAbNsBNptHldP9/v59G
-03C6mQ4+Xz0Lzqfn53a36f7k6cn3f8QpnPiDHQ6n302j6XS6uXoWBOdBtCXej9YUoBRCacXpS9ov5QP+
-iuh42b7VbrUJGsJC9y1oH+yKaqv74CsXr/h6Osu1Fhz0XUYnnv3iQZwSpSbeTHOYaT5I6JzkqfbOLuyH
-08gCnm3dRzteO2vB45TFN9+u4xbTQ4qOJKOiO6IfgDTsGFDHUCbZisg78/lWeUHrYrwWXKMpat+PNl7H
-nNcH9yn4lqv0idxQBb+BR7wxeCOvD2EYOjtqbhpiUHCapGeniT4jp1Giz06T5Gx0GiXJWRjaW7YiN/Rz
-QueMM5T455QpXTsAtJttuSPIA6JP0i0PhHfvht7hhW8jLK5m8iBK3fY8iW6J+obeNSGQypBkGeXJ6yVL
-Ez/RLRxm24fC2CTZ2jZpbYvsXN7Qu6uvbZ7Ur1f7nBG8ecBzIVdEf9ZsRVVG6lciGfUhOWifScLmcyTn
-AAaQjJpRwmeMETyvGmRz8M2CUxi2z8ICD7wW3xb9AP928VGEqD8RvQznqRDSbhDBq5eHQ/xXD1Fx5vtJ
-OdWkdtmJ5vnLXVjcTBPJqhPJyx0oXm4jUJ0IRnb9thWCc/AT2Acv8QIYN4RNYH8Cvk/gyxdYBgi4RMCl
-Awy6IVcGcoWQqwISI3kcUN6WU/iME2TLRizzFcFQiiRkllLIOdPWdJtPNaVKRVxXBFSQVMRwCqPhweEO
-V4IA++D9yevQB5ybmP8jg+KbMYdavGG3NPEPkG3vr3/yTr5lm52IfioQ3ff37vf2ogg4WQNTQCBlWqOQ
-NEuZvgMtQGkhKegl4wsTrLhEoA9KgF4SDRkVWUohJtyGmYybVfl8Hu6hbiDuMkGPIBUkcaQpg2xN0hyz
-Xejd0LtescWvH965kH7PMkmS2lHd0Lv2UX3364d3HymR8fIXk4C0xZpJsZBUKd/7UUohxzCTYqOohERQ
-hbmvyrNMSA0tPCG8/RGEhM2S6HOo+bmaiHmeprsNG6ebNlK/TG6UGQ0wGjFcNcLdKHLiN/9bcS3YmnK0
-9Bj4J1Z6aofUzLKm2BpyQeNv0zWYPIrMlktz7qyN1ojbCk9ZtvoGxviITheAhHC6sbug7T3HeNvh0MKF
-e0HTelcLvjP636C0Rc6SoSzuwixXy4+aaOrjmfXB/V9g2iLO3pAyrCyVaEWVIgtabBMLrkRKw1Qsyqm9
-gjE0jk987w8JneULLzDOs+Blaxy5ggskMAhCLd6JmKT0E1vRQgZ4geELoHzcTjgy5ThAgpMGubZQhvwq
-f05Yqvqg8jimChMmoUla0I9+DZN9+pZrCxn0jfkvB906R7auT1lMJyUipn4mP/tzNN54jmNw3lKVc6qa
-UxVCO6erORcOuGvUO52dnWIcUITWKK9BQviCSu+sB/swh/3y+HqnEcKePeUzlZ2M7Z/t5Y4tu151rAe7
-MrJ/EErDPs7OznpNYadisWZ0Y6LFPnCyKrXDmKf6AP7D76jpqVhY53G/tztF8RAzleFSr9LzOUvpBI+b
-qphk1IanbscGRYK/YSl9x5RGzbF0UTR+BR2VTfwvoQHxmijMGbkoMjbFVvUUXCvBU8Zd3lNkQwCtm+Yh
-mp/JimJEHkajF6+Onh+8eH5wNJiTIxo/j4+S4TF5Fc9nRy8Oh8dH86PjZP7qYDR6FeIOXr+JjTtMH+94
-DJoqtNNM0zaY0kRqhNuaYKt3YmGyA0vJwavhYDic0Tk9fDV89XI2ovOjw/lsNjqeHc+OksPn9DAZKLbK
-U6KFxBvdRpkRpajyxjBsTZiL0zGu2L+Qh8PnL1oTccoo17jk8qo1lVBbeGCCI/GflkxZzk0xjiqtYE0l
-mzPjFogGh8v4YYXCMkUgSuIlCL2k0uQdbD6nEoP2lUioCqcc3mpjvBgiRMwbASqjMZszK27Vh5nQS8hN
-IXBB9RKIdUSSGlwxdVv3DTxC6SVV1KAiiAuDrCmf8gH8bUkNKbjcLhrkPKFyYA62STluVV9jtm6CdKLp
-Tbd1yDCCB1O7aC7jRgOtTWBojTRqr/nuLoGROVquyyvzHW+DKwWi0Q3CuZA/knjpl06WpnTVB8YTersV
-o+Bcd7zX5RXF7Bom8JeP738OjbE1qINa0ccSZ5yaf2ngQ3MR+vjJGJi+HTSXwH52eftrd2ZmiKBDwE/F
-3a0VJet7iNm1nbi3f0phbQsvvCCafMLPfsUuCm/sMFZnlJEFfWdKsWN4Ubs7JNfibyzB4TlJFa1mhEyo
-HMPl5bAPPbwpvava/YlFmq+4GsNlQ85NqRunw3RK0bagzADTzJbmVDQ7A7M9re8yRJEQ3bV4Y8n3Rgfh
-C7rqAJCUG1Z21hq6os0iQjCwoRZvP77fjpBKdWpu2vr6gFA6zGxNHsYu72b4YPi9929u/NoZxZ1bxzsB
-it2fb+1u9AL9Pmr3GDyapixTTP1OR2KswrVg3Pf6rRThPzmDX0zNbYccTOi6UwTH/7m6mQoJMmb8Gpxt
-10k6JOE9/cPtwdHoxQmczs7eEJaCj6FKDQ/Gr5GJX/3GqPlifasJcgOMsbzO/e73vk7B89rGFqtB6v1u
-h/NuKzKomwsbdjygol0aauwa2swti7fzANHkfkU5i8DUK/vmGCvjut9RFiQ5/zc19WA4zG5/H2E8pM3o
-6DEQg4kLrk35x6ogjgedq2aawwRTj0eU+NWqXhj3mslKKkhCpQdSYPCuNNG58oBIRgZLliSUTzwtc+qd
-ubyjmarcau8MZQy+yVOQjX3oBQVs0UvodasB2ZgCUYcW2AvonH4fvEsMwa+84CF1Qnb3wbMJESKRZPMN
-iuQc9X1RQnzi96q4AfRMJHe9IBTc75l2QK8PRR+j391SjiK4cLVrUwEAMhO5BpWZNwBqbDpiahxFC6pn
-QmilJclMWywRsYoOw8MoLnqyKiqWNXtmOPo6tVZkAp4DGsyMjkLz60CtvMZik0BNqmAplGLjP/H1kqkA
-Azvc1+9p2QuCEGHrXhzXz12q2DisJohTT4zCHFopMt9LmMINE68PqFzBFk+cSpi41WG8ZGkiKfe90Glr
-EJIkMXz7NQk00aRkRtNOJKi1rqphDATjC6+5VvALwZG1Wl8TL4ZJU1dq0b7Lbo8t5ox9aMU+jr1Q0pVY
-020mmtDo6+zeXfbD8FjjhCbw/q9eR7DVINAYR6/frG+X1i2j/BNV2oRZv5AF9c0xtyDvgaaKPoIiTLvQ
-kdLk8WS9Fqss16i/5E4BF2PjlJ3kW3R01p1JUnJgqe+7Ey2qmLYc9cQvektBKClJ7vyte2x7dZLRNTVZ
-nemuibm5M6pVqijZLcoVYRg6vXoSkmty63upLXmYgkLq9Wvyc/WecbM2UlknlGEum694So1sqwZJqdQ+
-TtSyt8K8lf2HPxOepBQ4WbOFNVDGsLkG/xPfCzlZD1LGbzxj97yiIVpllOtm4WgdmsKu/5uHqZM3BroO
-NZELqkOW3NeEb92seU1DkuTHNeUaOaacSt/LRIa8oSpUtF0wldX64NsTaJyM7bZdg9YsqA3T8ZIq0MIc
-pCYzUDSlsaYJzO6KInVYFam6tnDcGitlah4TwzVqnO/Z+o8j0NxbHGi/hnh/8X4Mc3YLTIMSsKGglmID
-hfI469A3NXR7xNCzV7sHfs4tzWhfAhA8psB0TxmVp0m44woYOmrHJm6QqO33BHtV6X3RZM0cZwBfvoC3
-HmQsTdVgKVZ0oMnMdWxwGU3pyiXctkq+oDVpmNmnTw1UqMmsLphizPdQGF5JkG0CbZmk4mxqx1kW4PBk
-a6e4bc4MVD06LZsZjJuXZNVLxJpG79WqbEa1S0G4sMXIorqvThtcUQG36ze0H4XUhSGoi8Hx3zhN863e
-cTE1JDODu4T1uu+WEhg6IBGcvuEV+8aXs5QWld8yENsvF5VnXINrTm7bwSKar7mPNZFw/c/bJTr3J+GC
-6r98fP+zX39Guw9eVF/Y3x1EWzZ8E0BUdk5ww+8Fxiu2styi4T4wmV1l62/7sMOWVjyZ6jTMqY6XbdZM
-3li6qKBNnk0RoDLGzvlEz/bgGfxC5VzIlUJsmkrzptF2zLwfb2mcm4P8YOTjmTjLXP09eBZV50xvaWyX
-119fYfiKM064PRvC2cJXEJKM+YFrSdnXSokkG9+RFsEnV1832ynTLhVrKkmaOnxoHvI0PcHb897OFM+h
-DXRt6zpoxVQd2Dk3la/My50a/Bs7A26q2dV4I9KESh+dqRK5jGnfVV0LGbjEoAIoCqShF1wOr9w5wBvz
-nMNUiEv0cyFBig0kVJsyQF3g9vkHXq0LO+snNVvyj+QfwOwFFZItGCepjbTF7JrG9gGxKVOLTXkntHmw
-1ztN2LpIrdzGg5m49c56rhl1q2F/At5pdnY6O8No+zSanZ3OZHSG6mfTx9o7cj8JbXdnH7zTKDvzXGpj
-CihhrZIP303MG4ZK9Wtb1Zr75ejs7KJaXRHRBnQJbf2toN9FZY2UIOjYLiqpuK8x4JTCXo+wOKmHWDF0
-GzBDcybp2SmS0MHjY0nvJKKbCbPTaWR2bXNUQSVsXUw5/dW3+qTVP2vbuErFa5potE5pmcc6N+/2nYc0
-xY96s8wlgjUnx5Jmu6hsd13QdXaQQcJUjAbhDtaH3d2vxzSK4iWNb5S5ETOiWAyx4Hi53AsP0+Srb5RJ
-oUUsUhMc4SolVhRuuNhwUDTOJS7cUHLDKaZSYZ0caxn+m0rFBFetjpyH9LwmtnvWahuOtoYqCY22az9V
-YzATYv6RcKbvXiOf/vpwOHoedBSU2qL6AZRZZuWDkliRG7SB5r0Lsb9I4FRvhLwBRXWeAX60glS4LWa0
-HfuUzciD4cFwMDwcHBx8Gh2NRwfj0fNwOHp1eDwcHY7+3rWU8qR74VE4enk8ejF6+fy4c2HjfnSKsuxd
-emNTDeh3Q7irhVTAlH+1XFo79Ld8LnbvTA5fziidHe+EqI67gu3vhizOPyOojp9TDFP1QwsYV5pwzYim
-yQ87jucwPDh6cXg8enVw9PeHcKFXZOn2/pFrRxYMdLSPG3j+RtTbGlnuZB5Z877fVWK7b7Y5Czv1kWpb
-sAXG56IMj8vA9rMRqavYmGJTLZxrQuJN8gJjov1HWnDE1/A/tZzFTDYtRyuf+2CqOEBXmb6DtYUB+4OQ
-2o+Eut7ZPoC3bMt2wNm3Sh2qmtCUOuPeteTrdZOmJItmVkOYXe+RO4VUxLrtMpEJLZnCAGlJJXWmP77Z
-EJkMYrHKiGYz+8TP/OxApAlUyU1NopXoNeIjc00l/CXnFA6GB6PwcUx5VaZZ6OJrwddUajS6yFyZ98RE
-1bvt5U93bsrjLF1I/XDMMtujbgJd3rhW9n0re1oIbXIJu9L9KgpTDFxqBr2yhFNF6BAT3tMwoyApHg5J
-2b9o0ocNOgqaIDsJVVoKU+lYoXbpJb0DelswaVL0WtD+nY3CG1l6NR3GKSXSD0KHtV4aRpHXQGvCbsn6
-LWe6fMnjEpsiIF7W+vftRGZ3B9/Ip7uBPxp+Wwf/4Bs6+JioMKm0mzcvVjn06G1GeNIb2CrnVzpWtSZw
-r4j9Y8G1FGnv3+lBPdThcr/5cA/lx9DrPdQF22qCtTytseCx4IljfwzIxiMbdJ+6nePjmvlHx99/lTT4
-aHL7MWCEAUICF/rxzy90/kC/vxnaPKIN2FjwUHe7mVQg5Y/vcD/f0Zo+2fuW7rjpcZ88JsgqDuPFYxTl
-05Ji5m5D1eJlmKQYpCT1Wt7jW91ffY9hor9HHE4F/VCn1hBfuoH2v9LP25gupm8v0D98HXWBvlzH5wIm
-tYWXFcar3RjEwjmbzqZqHXnoIsV+c9QEVsG3v20wOz/6nUnjBdJDd7jdky3CB0W1gjwz6mKtLE1M8AhF
-F2Ov9vN0NY6izWYTVu4y5FRH9JasspSqiGQskmLz2Zld4666amjdTWCdhFv2evdvjLWsNUPjVCiqtO2x
-Nn/iIsUGJqUbNJ3Zxi+t0UpIsbFtzZCpj0ux4X7Q1q9CYIgOvVJq+lymKg4DMPsDa962CuuSJbT9cks3
-G5c9hfvWie/sDEYRvMcttaOle0N/u7CGc7b1HAQh7tVBT9kK7iCmUXNv1iQL2dYqLa4mgtL13Rv/qrga
-NFmqVV23QbseTVY4nXXvRIsq5+a7SrZb16io4XaiLou6TWqeRXv3e/8XAAD//5oNpr/CQgAA
+H4sIAAAAAAAC/7U8a3fbNrLf/StQNo3IWKIk24njl7xt3Ox6N2l6Yvf23rV9vBAJWYwpkktQD2/q
++9vvzAAkwYcUp+3NaW0Lj8FgMJg35MWRzFgq5DzM5Mc4ztgJs/r6c9/a2ppnQSih8fMWg3/9F/SL
+vWB/u3z/riciL/aD6E439un3NJuFt9QjDtlkHnlZEEe2zFJHAyFA/XciY9lUsLMP75kfsyBjkzhl
+c+kWYxY8ZT4s7cfefCaizPVSwTPxYyjwk93JxCrj0NRxjoo5vhtEkUgvoQtmwqJHxpL/I2SXnXdm
+jC9hHosnRh+gnSWH/b7MuHcfL0Q6CeOl68Wz/r/nQuIWZH+4Mzx4/WrQxx0We+8FUe8TX3DppUGS
+9T7B8PTBBHzOPs2Bxn4cdTLG71Ihyi2mIpunUY410lTh+9jd2tLTYR2ewHHAzpnPM86CSAa+YJxl
+XBEefhuEzh6SLvvzqQ1g15LZtiy2TWseNXcWz7PWnVU4iX2fZWkwhqGslac4dH+Rp1brsbdWloHb
+ypUiK1a0rbHVrWGPxOBhCABX9Q0Ym4MRrpyPYSrga7/qUkMoortsynrslbNux2dBmj2weRr2Ep5K
+mAusSKcDTWzK5ZRJcYd4V2hwJ7Jb7LyFSXwmDTqYREDMAb0FR+Q/P1baiTWheRlEPjB3GHsc57sI
+1djIsEYI+B8lAM12ZRIGcPeem5cOWcnGkQEMGxzBr2OapGkBDdvbJo454IQHKczAoVfBTQ76xASt
+qQ3bufIFHv8vH8/fxLMkjvBYEcDV4Ma5wZNf0z28cQpoj/UTVKDXndMb4iHJOBx15E3jtCcUP7FJ
+Gs9YZx5lKVxt4XdYGET3rIP3s9M4NOwzTgsOuctQdtWPjW+QdRUhx2v8O03FBFgYAFcGZep24q8m
+67bcyxzfT7KO8if5/4OxVQrOQxQhn+Qf2kAuMj+KJOQeHNwvH99JkJfwXzLPgO3hXpK0we1p0QdE
+k7dZTDuWm/QVu5xyGNLNFQVMTMWdWJGOKMaFArUpIN23bfv0EMdeyZvTw+v+dd+Bhqvr3tHh85Pr
+7etn3evlzfZfnNOr73v/5L3/DHoH1+5172b7Nxi2XC6v3d+ag+tjHVzkun91vf2/38ES7vXyund7
+88I5vT49Vatdb588P/ruL9iFHd+qZvf6Gxh+DUBfOM6p02+Q90KJAqSCmypy2qnoFvRh9oxn3rR+
+q/VsMhrcnPfV0C5TM8qlHp0vXLz84zEwTQZrgg4SJ5b6YDEv5FLCxyxi8H/PFxMORos1OlN/HPfV
+wFHjPqp246zjyAsD7/7reVxB2sToiDIyukZ6w0jaDg3VG0rSYMZBXuPfK2k5tYvxJo4yFEX1+1GH
+qzcHkPVfztdcpUt+D/foM7O4dcisIUBxXVfLUbppCEGyYz8cHfvZiB/34eex74+G8Jc/gsE0dAZg
+buGEgihAigNbyMw4AJSbdbrjkA2k98OGBsK7dy8e8MLXAeZX098IMqtrHj+rkRrAV0cgli5PEhH5
+b6ZB6Nt+VoNBy24yY32/saxfWxa3cwVr33xpcd+8XvVzxuHVAwaywaW8zYKZkAk3r4Q/7DJ/p34m
+fjCZIDo7YN74w6qVcIs2gmWVjcGE2TThmA3qZ6EG96zavhX4Hv5u20duor7n2dQF+zxO1QJ99vrV
+3gD/mSYq9nx3UnRVsZ22gtl9tQ6K7qkCmbUCebUGxKsmANkKYKjmN6UQOwWKgpq0fMthhxVic7YN
+drjN2W+/MZDKMHCKA6d6oNM+ckYjZzhylo9ESx4bpNVQCrfYwRsyYjqfcTSluM/HIRixcMeV6Ka/
+DKYCg9NkBGQQaAL+GA529taoEhwA2PxgtfAD9p3Qzz6B+GrIbha/DVbCt3dw29Y/frCOvmaZtYDe
+54CASo9bW0CjiC9YAJYkXMIsQyKBhgzACchi8DxisBiyKehbMla0IwAuSQx/84wlIk5giscjZWYG
+Ec2aTybuFvIGwi4c9D6gw32NmiRgYOHO0dtlHRAhnXwJsI20Sb+lNsl946hgZP2ovoEZF4Kn3vRn
+ckDqZE3SGJxbKW3rxzSN00M2TuOlFHBlYyHR95XzJInTjNXguOz8Rwbyewl7PWWGnjNIHM3DcL1g
+i8SyDtQunBtJrQ5aI7SrirkL5FLkp5+KXHfBQkQo6dHw9xX15Bqq0bQq2Sp0QeGv3DU4oaegWVNp
+Wp3VwRK5FfGk2laXxpCOaFUBiAisr1ZB2XuK9raGkcXa3HOq0ruc8A3xfwXTGjrTAGnx4CZzOb3I
+QL/ZeGZdpn/mkBrIqRtSmJUFE4FGkvxO5Mt4cSTjUIDPeld0beUbQ+H4zLa+9cV4fmc5pDzzvTTa
+cVfsDBF0oCV+B9sKxSVowJwGeIHZbwzpo1fClusIGzhANdFVgTLcr7QnHIxeuLVzD1wPdJjijIc5
+/qjX0NkX56DyaaTTJfFfNOp5Gu3M7FKQjgpAgfyJ/2RPUHjjOQL/qT5Z9MmyT5YAVV9W9mlzQF+j
+zvF4dIx2QG5aI716Po/uRGqNOrD9Cdsujq9z3Mexo+fRWCbgptCv5nS9LTVftsxnamZf/cJRGfwP
+veNRp0psOPtFIJZkLQJL8VnBHSSezAb8h5+R02GaUh6PW+tdFAshi9TF0N7pJAjBzwAshPR4IpR5
+qlesYBRHb2HoO2B95ByFl0Dhl+NRysS/xhlDuGSFaSEH0gdliorqSfB+4wiQ0X5P7g3Bv+pNsxDM
+T4ALWuRuf/jy9f7uzsvdnf3ehO8Lb9fb9wcH/LU3Ge+/3Bsc7E/2D/zJ653h8LWLK1jdKrRIQ7p4
+iDxwJSTK6SAT9WEy42mG4xodwewdUBi9A4XJzutBbzAYi4nYez14/Wo8FJP9vcl4PDwYH4z3/b1d
+sef3YBaGNOMUb3QdZALsIySAHNQ66OK0tMvgP7iHvd2XtQ5weMCCxilXN7UuX6jAA5wjIn8JAkzt
+nIJxQAfJFiINJgGpBVDDGhbpYYnEoiCQ4CAeYzjGlPwOsOBEikb7LIYF3OuInWckvAIEiJCXoLsT
+4QFgRW6QFGOYz+YUCASGnDKuFFEqCJYn9NJdGo+joFcKAsURFhpZ19F11GO/TgWhgtPVpN488kXa
+o4OtYo5LmXNo6eqQVjCd6yYP0UbwYIyLpj1uFNAZGYZKSCP30md9CYjmKLmubugz3gYdCkSh67ig
+CH8EItuFksUgXBeo7YtVw0bBvnZ7r00rxuNPsPDfLz785JKwJdCOEfRRyJFSs69ovEsXoYt/kYDp
+qka6BOpv7be/0WdGTRwVAv6V310jKGmuAUNUx6P6VRCrSTwXdBi/xL/tcrtIvEMNsTyjBHTYOwrF
+HrKXxt3h8yz+NfCxecJDKcqeOIXThktzNeiyDt6Uzo1xf7w4nM8iYLqrCp2rVCelE4Cti7IFacbQ
+zaxxTomzFjDNboygQDeMapu8VOhbwx33pZi1DIALRFtZG2toszZzC4HGgpFwfvGhaSEV7FRdtPZx
+A1FaxKxBD5LL6ze8M/jO+p0Lv9FCce3S3toB+eq7jdWJL1DvI3fDCBGGQQJS7086EpIKn+Igsq1u
+zUX4I2fwM8Xc1tCBTNe1JDj44+xGERLcGOk1NmrGSVooYT3/drWzP3x5xMBcewsTmY2migEH7dc+
+2a92pZU+KN1KRq6DNpbVut7j1pcx2DUWVlAJqPWnHc67hmVgigtldmxg0TYOJbmGMrMh8dYeIIrc
+LzBnbphaRd4cbWWc9yfSgvunv5NTdwaDZPXnEGMTN6OiR0MMVJUyrin8o1gQ253WWRjtPkHX4wkh
+fjkzA+NW1VnBAAb4KSyN0XgHbZLNpcV4GvDeNPB9EYFDks4FzFJ+R9VVWWXWCGnMbPJTcBvghDj5
+2DyX0GlnA76kAFELF6gLqJV+l1lXaILfWM4mdsLtwk1SDhECAfhfwUhaUT/mIcRndqe0G1g2jv2H
+juPCYXYoHdABDa/zGN32lDK4KWc6dk0RAMbH8RzOOqEaADADMMslD/t9sCDHcZzJLOUJpcX82JP9
+PXev7+U5WdnPp1VzZtj6JlRSBHw2Pag3Jh5l1Y/AB1ZlMjlQJ6Wx5Kbx0n5mZ2DSO2jY4bp2J0s7
+4PHjWFOL4/yJdhUrh1UdotkTrTANNo0T2/IDiQv6FtjmwFxOY0+ANUxSs10Pg/SAjW25mlsdl/s+
+7ds2KFAFE/KxCFuBINfqqAYJCDBQrOrcODoDsiNtyrwmXgxyU2fyrn6X9RqNzZF8qNk+entuKmbx
+QjQ3UR2Nuk6t3SY/aI/GToTPPvzDajG2KgiScMSUWdvIOBHRJbglZGb9DBawTcdcG/nIBOzsCRih
+24WKFMjxZLSwEgEza0zyB8mi+JCUsqZ8DY/WuDP3ix0o7Lv6RPMopgpHPbPz3BIma7n/YDfuscrV
+pYFYCPLqKLsG7ihyu6yFKort5uEK13U1Xz1z+Se+sq1QhTwooBDCVj8b3gzFew6rsZFSOiEN52m1
+iqfgyDpr8FCkmY0dhveWi7ci//A3HvmAacQXwZ0SUCTYdIIf3Cbw1BY9DPNYJPesPCFaepSLauBo
+4VJg1/5soesEfq1YuOCegHxzA//RIL5Ss1RNAxf5xwUcAe5YwL2wrSROcG/ICiVuZwFolDIP3uxA
+4USyW2UNar1MLgP4hTGJmA4SBB6T4LZ6GdyZ8UMepHbLIFXbEnq3JKUo5nFCu0aOsy0V/9EI0r3F
+hno1xIezD3CIwQpryWTMloLJabxkOfNo6dClGLo6YtZRV7vD7HmkcEb54mBiWgCYjiSWF7675goQ
+HsaxxfeIVLOeYKsMvd9Vt0bH6WDqy1r0kiAMZW8az0QPENEZG5yGUQDtcKso+Z0wqEG9z5/TKOCK
+sUmYvA2ICMSwCoRUEqghkvKzMY6zCMDhyRqn2BRnNMq0TotkRhBRJVlZiWhw9JYRZSPWLgihzRai
+RXlfNTfooAIu161wPxKpDYJjkkHvv3Ka9MnMuFAMiXpwFdeM+zaYgPDAkkrxNiq3T7ocevLIb2GI
+bReTijM2xlU7m3Iwt+YN9YFpt0//Xk1RuT/DKDLGkGyzjBYMuL45sbveiFbbsMmAKOVcHNF+Mcqj
+I8s1HB4d8uxKWb/qsjWytNwTRafZRIAUqG+N/MZCRTl19JSLwEphrJUPxqlfsJ9FisUEEqGBxqOa
+RpUxs35cCW9OB/mR6GORnUVXfwuDhMU5CxioppvVV2i+Yo8mbkeZcCrwBeZTEsBPlVtS1Uo+WMu2
+Rg2LtkS5nKR0KRb38jDU8FA8gPd0hLfng+rJy6FptLG0ObTclDlYKzc5n1HljjH+rephuqua1Xgb
+h2AM2qhMZTxPPSCzCsHkNNCOQTkgD5CCXr4a3OhzYG+pnIMixAV4TCOCOcx8MI0xDGASXJV/4NU6
+U722b8iSf/n/wqwEnmGcBndBxENlacfjTyC6CTKFqeNlcScyKtjrHPvBInet9MJgu68szOiQVwvD
+tuGCHiej4/EIrW0MRRyP0/4I2U+5j0Ydue27KrsDLHrcT0aWdm0ogOIakXxMVGINQ8n6xlJGcr9o
+HY/OytklEvWB2qE1awXtNiwNVBynZbl+gcWjsQHNFOp6uPlJbdoK4U3DCOckFaNjRKFlj09FvRWJ
+9k3QSrCXlH5Wd6RHKdYCYMSuv2AWFdZQXH0eTWLnqDlaxSXfo5nqyQ2jAQPgr3xZfTcyrIEr7jyY
+9DxVlq435SnaRjqPDzavgosXl5Bjc8TO3fLypxDUqtHAvAQt8/lePGBoNJmDqPNgPrp8eUD151/Q
+mqRNGIYtGpU5eouiQmRIXPyddcQetSmrQc/AjwL6X1wYkN9TG1ONxQL0+4cHUJhVCJHIPq6M2T+B
+nvv4360zP4LR0Zh8WZ98+dTJ4zD27j+C62EAAGvznqqCvgbGrykFxatAlrpxLRSQgHWpRuSxF2b9
+QKYKkvBIrR/QP/tHQL/eq19/Vb8u4ddNaQuqknZl6E/RIbIXbHRCdUBoBGKpO0Et6/6Hlao51leD
+jcqK7e2jZj66ZBBYEZZkp/D/IVPcQkqZlrkKbqpJ8Sotyg1rsDVioFWChV3qorRcUh3yw0olujh4
+X6oXheQ+ZT31PYIRnIw3F2H+gI8FyBPgqE1hPFgvKRjq6Ackgt8zOwzupqDa1IV0GSb32IyuG+ob
+ifUYPoLSJvEUhqNVTJP5LFb5zzwdS2VSZmp2DF3gd8P19906maryyJAvhj+Uw8KE5NFWpbg08NG0
+bk7LJXnZcxX4N25Ot0bEQy+hUn7VSc36mK0q9ILRkElMwHlw3qo7QURQegxS287MPFwt70yINPFq
+5mJRUXkLKiC8Up42dlmbTnWOM76yzXYwdEoy6TYXD7qFDsY9psg2wNwZDLpsKpCf4NNwp7y2yhDR
+qvJjhYFJY5Zq64t7KbQOKZ1W88QrzROVFGkbEvgq69JmhqjYrLKYrq2cIj26gteWqdWffHrF8YPx
+C6Ro0rlZpgyng+6QeW7gKQ+bA/ni7tf8SNN4Hvm2OpEXtJoL3ayP0FpKofFwN82lW942uaRVOjrO
+fDoJ8LVRTyizENqovXVa51gCUrTSiYUhfrXoNutYmoFUs2YmbM+N1QakFK1fA5Ta0iZQ6BWFJ9a3
+B8LjYogpCITx5AWI3E+Bvzt8vTP2vwAfSAW0GBHBrHU09kd4iIrGSnba+dESF3eVOG4OoMtbHkgf
+Tqu1qLZEhnh/VLHpHtdYdi12YqGwyLJFnUH1r6ghZnoEmOQ8AQuQSlpy8W5orZqSqJqh7UpCixfr
+KxSEEjEnrCrvqwWX33iuRvptS9YRZB/4dnOxrr4Tg0/nZ4iXrtnRsHp4Sih/qimNTyrTAqq6umMc
+TSUx6jlgMHmwFWR18C3dNazbhZxyCgNfMa8SkMazTmON6tWruDHG8zR6lGZdoKWRHzScqdpqEc6w
+860rpFq9mQbf4UnQAdcdsFYVmzcqVaNImWNUqBqsaqw7KFKkWFuGfkoSxpmO3+pnTGBsCoqfPKgB
+ILzx6Yh2UvQgdWIXCk7uqPQTAdfh9Fk/0CXOUzDBbyWsUDbZoFiC6JZ6yOz+jX7enkbz2Vik+Scl
+YRyad6Mi0g120WE8nluD+VlQ8oBsPlhZ5puFEwoyKmDf0sWP+YtKepxXBgPPz4x72eRRxSxd1nJb
+KgHc4s0NMI5+cPPDw7mfM5vmBxW1raR6NGDKfOhAJ1WQV0N8xvIb4nukhfksCYVpVVYEB5Z9InHq
+xW91EUAPG2CsC+vObKcZIyiML7WeMjGNAjec7Dwl94T14nCL8rr1C5HZbe+t6Ej1YnU8ikF0HUEw
+SnfNyLxcV2IWxW5L07WgmKP3fZryBxeFO82UjivjNGvUshP4NbZzjQl+igs2XooUfSIPE8++azVf
+JtcqC6uHTjkOlUk4Vh9GmPKlF1s2NsYUKhoVWdzvfV9fFeK8NooTMYHoaq91m5sW2bAC0XbtCwGy
+N6VGmOSiMVTDpgTaFIvCrTX1AkXaiCZcDW7cPEF1jjWb6+qc1OI57voA3mCjrdmmm+MArqPtOC15
+2HVLVryXGkMZYIgHxCzJHuzimBSgrkbPfJdVHEmq7m5TKLfZ4zrpoPhxEsAKBREjI2CUwh0H6wTa
+jioomvz8e6lYZ4K2G/YIyhU5xqpnGZrpLuLc96UOxgKJXB2g8opi9BgACNVmWLX0QZ+ZSBrxB5KJ
+RRCCF/pSsov/+quhHTbssRL8yZ3GVzWnET4lHJ8V7pXuYxIHa2IA6wRefiYb5ZwCq4Ty5+yQiky1
+YJYulsc6XbY4LOdfIcSbxzVBATJEB/hQhMAi12ON+LBs0euVoSkYUW5yMQuiwjEPIuSZ8EE/29Ez
+ZzwpTz4x2DNxF8iXjgmNvMfczf+D0LwYJK4sdtKcWpFbK3otg0aeDdsfIVVOmZ24Gew5A2HTp3b1
+9yFKnxdMe509tgMfYG6tJAiTN5pHegS6x2za4UiRjcAvoBU/0QLUm3+GRdyXtEwBpLmO3vyqFhom
+C/vBaDyq1nOZ4Q2U0cfJmqiEoUdza5fZiDwOp12QI4d4qxb4g1rwk+Gfl7TTb1PpeahjxFB+v5MN
+Tv8D1uuNdcX5MFkxGYdgsH/r+349YdQ5TuLwgQSD8nijOBIII43vRen/6oaexmTovrQ0H6n1FXPp
+cmJmaZ8D9qOBN9JU2mFen3UwXgTVs7Zl0s7IrVEeDbCce9lcaQ+V+aZyTvP5jy5tM9L2gV99AFM8
+4DkTi2QnYX4gPUxxPrDFXvt7nqc8ffGmwgN3GsXemMvAQxmK7rF+s0rPlsyFkjTOYi8OyeTHWTIG
+WXgfxUsQh8KbpzhxKfh9JLA4zDXRUariv0Dq47cs1d4YWYjPG67eA9UeQg0bTSWFhs1q1vKpUxLH
+kwseAU5vcJ/2Ym8w3HVaSmTrpPoeRD9OU/RBSuA3GwDxKNnE1XcsRSJbxuk97DubJwz/VISUuCzW
+6LWsUzyv2hnsDHqDvd7OzuVw/3C4czjcdQfD13sHg+He8J9tU0Fhtk/cd4evDoYvh692D1onVjJ+
+raQsXmNBL5YndNtH6GQhYsHAa/lSrapx6BgGWb8y33s1FmJ8sHZEedzl2O76kfn5g8aFM7zFr9SS
+2aYJAdgNPMoCGOh/v+Z49tyd/Zd7ByB59v+5CRbm+cFPbKzf18GafAMtD+IqcH7l8txAS5/ME6v4
+H9cVDT9WH27lcuoCdQ2FWAM4qaLgpyjVuSWSat+CnFdD6VRH4k2Ckaig7CfmpBFeJaNuVGFRZ1Vy
+1CrUPlJdKiNzHp/ySQolUNDK+Nqztm8O2QC3iNS1jFOvr1tY1Qc7WQv3tilfdnWrlMyf51SI2fYN
+K61Eys2JeuErxaVAEcB/U3R6lej37pc89XuUUcuCsfrSAorVxKHPynItg6Il6ckR4BOsgP37HHQ2
+XJ6h+7RNWWXtXM6Lb+IITjGjEC9srjQVuTTfDxZfRnZfHGehQszDoWnKFK8OurrXj/Mea/Vgd+DH
+UDyRZmqjGqOMOJUaraIotaw5wqRkJ8O0ZCrwcHgY/Ef4XSyYjITwcTvA42CwUO3mDLmLwn5ilW+S
+ig6NMqRvVF1RxREru+G8BU/Bf9VQzUAIktwYahC7RutzQLR4m6xLtfISn6nxIrFemrX+TSLRp/1J
+ItiUX/Umcecr3iRi6VWQ4ttX6qfv4IhYR6wSMFQ6PVW3/YU3OMaztk5ezYSx+DQOO7/nVc2mNzs6
+iKC/+gcW7Gx619N41lPTtCTBAVVfb/+Q4Tae+OTosl05Pu154v7Bd19EjV1QteIhQwsDvwEkirOn
+PyjF5z3rX4dVTJsnPGyqTNj0Xq9aJoWYP/3N3u6ax3ZHT3htV773o1d7R08xsvLDePkURsFKSTA9
+lKmaZ8lSgUaKb1YnP/3x3hdfmJL194TDaU+o1QNrhHyhBur/yhwdGU+eOD9bn6trA1/Mg7G1NF4B
+8WYjBPrynjXvxEzorjYVu9VW9fUT6xZA3qwMTzyevN3wdrH8EjHASsUGyjBC+b0Ya5HM4XfBnoU/
+LWfN68/NT0pVkf+dVsS4pPP1D1IJwJMfB1eejW8SU/WHdLmFBH6dZODZUfqKFInwyT5m+dOTLeM7
+hfFh3HK5dEuLwAUHsS9WKsTX50nQT+PlrdYspJHbCp/bX+5lvttQSeu/GDZLjRdsXhhLjDDTw7jq
+95JhwfBJoenpOV3l63GR2VL8emR8i+YGErOwsFqd1XKCIThUvCE9TqKnDKzHaH0WVAVKCXUagAtQ
+rwGpvjbrYGKw8vWLrc+5sLI7oWyqwqV9QbtZDY196r2g47i4Vgs+xfu9FmQqDyWqheQ5bY1gkg77
+IHVt/cVMZUW8U0tWlaXyzaFt2Z0SplZgrWCR5XR/W5194xrlhfetoItK/Co24F0+bv0ffGzK+Hdc
+AAA=
 `,
 	},
 
@@ -31,16 +31,20 @@ func main() {
 		simPattern            = flag.String("sim", "", "Regular `expression` selecting the simulators to run.")
 		simParallelism        = flag.Int("sim.parallelism", 1, "Max `number` of parallel clients/containers (interpreted by simulators).")
 		simTestLimit          = flag.Int("sim.testlimit", 0, "Max `number` of tests to execute per client (interpreted by simulators).")
+		simCheckPostState     = flag.Bool("sim.checkpoststate", false, "Verify the expected post-state of tests (interpreted by simulators).")
+		simReuseClients       = flag.Bool("sim.reuseclients", false, "Reuse client instances across tests (interpreted by simulators).")
 		simTimeLimit          = flag.Duration("sim.timelimit", 0, "Simulation `timeout`. Hive aborts the simulator if it exceeds this time.")
 		simLogLevel           = flag.Int("sim.loglevel", 3, "Selects log `level` of client instances. Supports values 0-5.")
 		simDevMode            = flag.Bool("dev", false, "Only starts the simulator API endpoint (listening at 127.0.0.1:3000 by default) without starting any simulators.")
 		simDevModeAPIEndpoint = flag.String("dev.addr", "127.0.0.1:3000", "Endpoint that the simulator API listens on")
+		metricsAddr           = flag.String("metrics.addr", "", "Serves Prometheus metrics at /metrics on the given `address` (e.g. 127.0.0.1:6060).")
 
 		clients = flag.String("client", "go-ethereum", "Comma separated `list` of clients to use. Client names in the list may be given as\n"+
 			"just the client name, or a client_branch specifier. If a branch name is supplied,\n"+
 			"the client image will use the given git branch or docker tag. Multiple instances of\n"+
 			"a single client type may be requested with different branches.\n"+
 			"Example: \"besu_latest,besu_20.10.2\"")
+		clientPcap    = flag.Bool("client.pcap", false, "Capture network traffic of all client containers to pcap files in the results directory.")
 		clientTimeout = flag.Duration("client.checktimelimit", 3*time.Minute, "The `timeout` of waiting for clients to open up the RPC port.\n"+
 			"If a very long chain is imported, this timeout may need to be quite large.\n"+
 			"A lower value means that hive won't wait as long in case the node crashes and\n"+
@@ -86,6 +90,15 @@ func main() {
 		fatal(err)
 	}
 
+	// Start the metrics server.
+	var metrics *libhive.Metrics
+	if *metricsAddr != "" {
+		metrics = libhive.NewMetrics()
+		if err := startMetricsServer(*metricsAddr, metrics); err != nil {
+			fatal(err)
+		}
+	}
+
 	// Set up the context for CLI interrupts.
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt)
@@ -105,7 +118,11 @@ func main() {
 			SimLogLevel:        *simLogLevel,
 			SimParallelism:     *simParallelism,
 			SimTestLimit:       *simTestLimit,
+			SimCheckPostState:  *simCheckPostState,
+			SimReuseClients:    *simReuseClients,
 			ClientStartTimeout: *clientTimeout,
+			ClientPcap:         *clientPcap,
+			Metrics:            metrics,
 		},
 		SimDurationLimit: *simTimeLimit,
 	}
@@ -113,6 +130,14 @@ func main() {
 	if err := runner.initClients(ctx, clientList); err != nil {
 		fatal(err)
 	}
+	// When all clients are captured, build the capture image here so building it does
+	// not count against the client start timeout. Simulators can also request capture
+	// for a single client, in which case the image is built when it is first needed.
+	if *clientPcap {
+		if err := containerBackend.BuildPcapImage(ctx); err != nil {
+			fatal(err)
+		}
+	}
 
 	if *simDevMode {
 		log15.Info("running in simulator development mode")
@@ -136,6 +161,10 @@ type simRunner struct {
 	// This holds the image names of all built simulators.
 	simImages map[string]string
 
+	// This holds the service images of all built simulators.
+	// Map: simulator name -> service name -> image name.
+	simServices map[string]map[string]string
+
 	// This is the time limit for a single simulation run.
 	SimDurationLimit time.Duration
 }
@@ -158,10 +187,12 @@ func (r *simRunner) initClients(ctx context.Context, clientList []string) error
 		if err != nil {
 			return err
 		}
+		start := time.Now()
 		image, err := r.builder.BuildClientImage(ctx, client)
 		if err != nil {
 			continue
 		}
+		r.env.Metrics.ObserveBuild("client", client, time.Since(start))
 		anyBuilt = true
 		version, err := r.builder.ReadFile(image, "/version.txt")
 		if err != nil {
@@ -183,14 +214,32 @@ func (r *simRunner) initClients(ctx context.Context, clientList []string) error
 // initSimulators builds simulator images.
 func (r *simRunner) initSimulators(ctx context.Context, simList []string) error {
 	r.simImages = make(map[string]string)
+	r.simServices = make(map[string]map[string]string)
 
 	log15.Info(fmt.Sprintf("building %d simulators...", len(simList)))
 	for _, sim := range simList {
+		meta, err := r.builder.ReadSimulatorMetadata(sim)
+		if err != nil {
+			return err
+		}
+		start := time.Now()
 		image, err := r.builder.BuildSimulatorImage(ctx, sim)
 		if err != nil {
 			return err
 		}
+		r.env.Metrics.ObserveBuild("simulator", sim, time.Since(start))
 		r.simImages[sim] = image
+
+		// Build auxiliary service images.
+		services := make(map[string]string)
+		for _, service := range meta.Services {
+			image, err := r.builder.BuildServiceImage(ctx, sim, service)
+			if err != nil {
+				return err
+			}
+			services[service] = image
+		}
+		r.simServices[sim] = services
 	}
 	return nil
 }
@@ -249,7 +298,9 @@ func (r *simRunner) run(ctx context.Context, sim string) error {
 	log15.Info(fmt.Sprintf("running simulation: %s", sim))
 
 	// Start the simulation API.
-	tm := libhive.NewTestManager(r.env, r.container, -1)
+	env := r.env
+	env.Services = r.simServices[sim]
+	tm := libhive.NewTestManager(env, r.container, -1)
 	defer func() {
 		if err := tm.Terminate(); err != nil {
 			log15.Error("could not terminate test manager", "error", err)
@@ -273,6 +324,12 @@ func (r *simRunner) run(ctx context.Context, sim string) error {
 	if r.env.SimTestLimit != 0 {
 		opts.Env["HIVE_SIMLIMIT"] = strconv.Itoa(r.env.SimTestLimit)
 	}
+	if r.env.SimCheckPostState {
+		opts.Env["HIVE_CHECK_POSTSTATE"] = "1"
+	}
+	if r.env.SimReuseClients {
+		opts.Env["HIVE_REUSE_CLIENTS"] = "1"
+	}
 	containerID, err := r.container.CreateContainer(ctx, r.simImages[sim], opts)
 	if err != nil {
 		return err
@@ -281,7 +338,7 @@ func (r *simRunner) run(ctx context.Context, sim string) error {
 	// Set the log file, and notify TestManager about the container.
 	logbasename := fmt.Sprintf("%d-simulator-%s.log", time.Now().Unix(), containerID)
 	opts.LogFile = filepath.Join(r.env.LogDir, logbasename)
-	tm.SetSimContainerInfo(containerID, logbasename)
+	tm.SetSimContainerInfo(sim, containerID, logbasename)
 
 	log15.Debug("starting simulator container")
 	sc, err := r.container.StartContainer(ctx, containerID, opts)
@@ -351,6 +408,19 @@ func startTestSuiteAPI(tm *libhive.TestManager) (net.Addr, *http.Server, error)
 	return laddr, server, nil
 }
 
+// startMetricsServer serves the metrics endpoint in the background.
+func startMetricsServer(addr string, metrics *libhive.Metrics) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("can't start metrics server: %v", err)
+	}
+	log15.Info(fmt.Sprintf("serving metrics at http://%s/metrics", listener.Addr()))
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	go http.Serve(listener, mux)
+	return nil
+}
+
 // shutdownServer gracefully terminates the HTTP server.
 func shutdownServer(server *http.Server) {
 	log15.Debug("terminating simulator server")
@@ -1,6 +1,7 @@
 package hivesim
 
 import (
+	"archive/tar"
 	"bytes"
 	"encoding/json"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -100,7 +102,8 @@ func (sim *Simulation) StartTest(testSuite SuiteID, name string, description str
 
 // ClientMetadata is part of the ClientDefinition and lists metadata
 type ClientMetadata struct {
-	Roles []string `yaml:"roles" json:"roles"`
+	Roles []string          `yaml:"roles" json:"roles"`
+	Hooks map[string]string `yaml:"hooks" json:"hooks,omitempty"`
 }
 
 // ClientDefinition is served by the /clients API endpoint to list the available clients
@@ -119,6 +122,12 @@ func (m *ClientDefinition) HasRole(role string) bool {
 	return false
 }
 
+// HasHook reports whether the client declares the named hook.
+func (m *ClientDefinition) HasHook(name string) bool {
+	_, ok := m.Meta.Hooks[name]
+	return ok
+}
+
 // ClientTypes returns all client types available to this simulator run. This depends on
 // both the available client set and the command line filters.
 func (sim *Simulation) ClientTypes() (availableClients []*ClientDefinition, err error) {
@@ -181,6 +190,47 @@ func (sim *Simulation) StopClient(testSuite SuiteID, test TestID, nodeid string)
 	return err
 }
 
+// StartService starts an auxiliary service container. Services are declared in the
+// hive.yaml file of the simulator and built from the simulator subdirectory of the same
+// name. Like clients, services can be configured using start options and connected to
+// networks. They are stopped when the test suite ends. Returns container id and ip.
+func (sim *Simulation) StartService(testSuite SuiteID, service string, options ...StartOption) (string, net.IP, error) {
+	setup := &clientSetup{
+		parameters: make(map[string]string),
+		files:      make(map[string]func() (io.ReadCloser, error)),
+	}
+	setup.parameters["SERVICE"] = service
+	for _, opt := range options {
+		opt.Apply(setup)
+	}
+	data, err := setup.postWithFiles(fmt.Sprintf("%s/testsuite/%d/service", sim.url, testSuite))
+	if err != nil {
+		return "", nil, err
+	}
+	if idip := strings.Split(data, "@"); len(idip) >= 2 {
+		return idip[0], net.ParseIP(idip[1]), nil
+	}
+	return data, net.IP{}, fmt.Errorf("no ip address returned: %v", data)
+}
+
+// StopService stops a service container.
+func (sim *Simulation) StopService(testSuite SuiteID, containerID string) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/testsuite/%d/service/%s", sim.url, testSuite, containerID), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("can't stop service: %s", strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
 // ClientEnodeURL returns the enode URL of a running client.
 func (sim *Simulation) ClientEnodeURL(testSuite SuiteID, test TestID, node string) (string, error) {
 	resp, err := http.Get(fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s", sim.url, testSuite, test, node))
@@ -223,6 +273,91 @@ func (sim *Simulation) ClientExec(testSuite SuiteID, test TestID, nodeid string,
 	return &res, err
 }
 
+// ClientFiles returns a tar archive of the given file or directory in a running client.
+// The path must be absolute. The caller must close the returned reader.
+func (sim *Simulation) ClientFiles(testSuite SuiteID, test TestID, nodeid string, path string) (io.ReadCloser, error) {
+	q := url.Values{"path": {path}}
+	p := fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s/files?%s", sim.url, testSuite, test, nodeid, q.Encode())
+	resp, err := http.Get(p)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("can't download %s: %s", path, strings.TrimSpace(string(body)))
+	}
+	return resp.Body, nil
+}
+
+// ClientHook runs a hook declared by the client, e.g. "enode" or "reset".
+func (sim *Simulation) ClientHook(testSuite SuiteID, test TestID, nodeid string, name string) (*ExecInfo, error) {
+	p := fmt.Sprintf("%s/testsuite/%d/test/%d/node/%s/hook/%s", sim.url, testSuite, test, nodeid, name)
+	resp, err := http.Post(p, "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("hook %s failed: %s", name, strings.TrimSpace(string(body)))
+	}
+	var res ExecInfo
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// UploadFile stores the content of src in hive's file cache and returns its hash.
+// The file can then be added to any number of clients in the test suite using
+// WithUploadedFile, without sending it again. The upload is streamed, so src can
+// be large. Uploaded files are removed when the suite ends.
+func (sim *Simulation) UploadFile(testSuite SuiteID, src io.Reader) (string, error) {
+	resp, err := http.Post(fmt.Sprintf("%s/testsuite/%d/file", sim.url, testSuite), "application/octet-stream", src)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("file upload failed: %s", strings.TrimSpace(string(body)))
+	}
+	return string(body), nil
+}
+
+// CreateVolume creates a volume which can be mounted into clients using WithVolume.
+// The given files are copied into the volume. Map: file path in the volume -> source
+// file path. Volumes are removed when the suite ends.
+func (sim *Simulation) CreateVolume(testSuite SuiteID, name string, files map[string]string) error {
+	setup := &clientSetup{files: make(map[string]func() (io.ReadCloser, error))}
+	WithStaticFiles(files).Apply(setup)
+	_, err := setup.postWithFiles(fmt.Sprintf("%s/testsuite/%d/volume/%s", sim.url, testSuite, name))
+	return err
+}
+
+// RemoveVolume removes a volume. The volume must not be mounted by any running client.
+func (sim *Simulation) RemoveVolume(testSuite SuiteID, name string) error {
+	endpoint := fmt.Sprintf("%s/testsuite/%d/volume/%s", sim.url, testSuite, name)
+	req, err := http.NewRequest(http.MethodDelete, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("can't remove volume %s: %s", name, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
 // CreateNetwork sends a request to the hive server to create a docker network by
 // the given name.
 func (sim *Simulation) CreateNetwork(testSuite SuiteID, networkName string) error {
@@ -283,6 +418,20 @@ func (setup *clientSetup) postWithFiles(url string) (string, error) {
 	for key, s := range setup.parameters {
 		formValues[key] = strings.NewReader(s)
 	}
+	if setup.readiness != nil {
+		probe, err := json.Marshal(setup.readiness)
+		if err != nil {
+			return "", err
+		}
+		formValues["readiness"] = bytes.NewReader(probe)
+	}
+	if len(setup.uploads) > 0 {
+		uploads, err := json.Marshal(setup.uploads)
+		if err != nil {
+			return "", err
+		}
+		formValues["uploads"] = bytes.NewReader(uploads)
+	}
 	for key, src := range setup.files {
 		filereader, err := src()
 		if err != nil {
@@ -354,3 +503,45 @@ func wrapHTTPErrorsPost(url string, data url.Values) (string, error) {
 	}
 	return "", fmt.Errorf("request failed (%d): %v", resp.StatusCode, string(body))
 }
+
+// extractTar writes the files and directories of a tar archive into dir.
+// Other entries, like symbolic links, are skipped.
+func extractTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Entries must not escape the destination directory.
+		target := filepath.Join(dir, filepath.FromSlash(path.Clean("/"+header.Name)))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := writeFile(target, tr, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeFile(file string, r io.Reader, mode os.FileMode) error {
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -1,10 +1,14 @@
 package hivesim
 
 import (
+	"archive/tar"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -29,7 +33,7 @@ func TestClientTypes(t *testing.T) {
 		{
 			Name:    "client-1",
 			Version: "client-1-version",
-			Meta:    ClientMetadata{Roles: []string{"eth1"}},
+			Meta:    ClientMetadata{Roles: []string{"eth1"}, Hooks: map[string]string{"reset": "/reset.sh --full"}},
 		},
 		{
 			Name:    "client-2",
@@ -144,6 +148,109 @@ func TestStartClientStartOptions(t *testing.T) {
 		}
 	})
 
+	t.Run("readiness_options", func(t *testing.T) {
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1",
+			WaitForBlockNumber(5), WaitForExec("synced.sh", "--quiet"), WaitForLog("^ready$"))
+		if err != nil {
+			t.Fatalf("failed to start client: %v", err)
+		}
+		atLeast := uint64(5)
+		want := &libhive.ReadinessProbe{
+			RPC:       &libhive.RPCProbe{Method: "eth_blockNumber", AtLeast: &atLeast},
+			Exec:      []string{"/hive-bin/synced.sh", "--quiet"},
+			LogRegexp: "^ready$",
+		}
+		if !reflect.DeepEqual(lastOptions.Readiness, want) {
+			t.Fatalf("wrong readiness probe %+v", lastOptions.Readiness)
+		}
+
+		// Invalid probes are rejected.
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", WaitForLog("(("))
+		if err == nil {
+			t.Fatal("no error for invalid log regexp")
+		}
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", WaitForExec("/bin/true"))
+		if err == nil {
+			t.Fatal("no error for exec command outside of /hive-bin")
+		}
+	})
+
+	t.Run("metrics_options", func(t *testing.T) {
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", WithMetricsPort(6060, "/debug/metrics/prometheus"))
+		if err != nil {
+			t.Fatalf("failed to start client: %v", err)
+		}
+		if got := lastOptions.Env["HIVE_METRICS_PORT"]; got != "6060" {
+			t.Fatalf("wrong HIVE_METRICS_PORT, got: %s", got)
+		}
+		if got := lastOptions.Env["HIVE_METRICS_PATH"]; got != "/debug/metrics/prometheus" {
+			t.Fatalf("wrong HIVE_METRICS_PATH, got: %s", got)
+		}
+
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", Params{"HIVE_METRICS_PORT": "x"})
+		if err == nil {
+			t.Fatal("no error for invalid metrics port")
+		}
+	})
+
+	t.Run("pcap_options", func(t *testing.T) {
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", WithPacketCapture())
+		if err != nil {
+			t.Fatalf("failed to start client: %v", err)
+		}
+		if !lastOptions.Capture {
+			t.Fatal("capture not enabled")
+		}
+		if !strings.HasSuffix(lastOptions.CaptureFile, ".pcap") {
+			t.Fatalf("wrong capture file %q", lastOptions.CaptureFile)
+		}
+
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1")
+		if err != nil {
+			t.Fatalf("failed to start client: %v", err)
+		}
+		if lastOptions.Capture || lastOptions.CaptureFile != "" {
+			t.Fatalf("capture enabled without option: %q", lastOptions.CaptureFile)
+		}
+	})
+
+	t.Run("volume_options", func(t *testing.T) {
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1",
+			WithVolume("datadir", "/root/.ethereum"), WithVolume("keys", "/keys/"))
+		if err != nil {
+			t.Fatalf("failed to start client: %v", err)
+		}
+		if len(lastOptions.Volumes) != 2 {
+			t.Fatalf("wrong volume mounts %+v", lastOptions.Volumes)
+		}
+		keys, datadir := lastOptions.Volumes[0], lastOptions.Volumes[1]
+		if keys.Path != "/keys" || datadir.Path != "/root/.ethereum" {
+			t.Fatalf("wrong volume mounts %+v", lastOptions.Volumes)
+		}
+		if datadir.Volume == keys.Volume {
+			t.Fatal("distinct volumes have the same name")
+		}
+
+		// The same volume name resolves to the same volume.
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-2", WithVolume("datadir", "/data"))
+		if err != nil {
+			t.Fatalf("failed to start client: %v", err)
+		}
+		if len(lastOptions.Volumes) != 1 || lastOptions.Volumes[0].Volume != datadir.Volume {
+			t.Fatalf("wrong volume mounts %+v", lastOptions.Volumes)
+		}
+
+		// Invalid mounts are rejected.
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", WithVolume("../x", "/data"))
+		if err == nil {
+			t.Fatal("no error for invalid volume name")
+		}
+		_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", WithVolume("datadir", "data"))
+		if err == nil {
+			t.Fatal("no error for relative mount path")
+		}
+	})
+
 	t.Run("files_options", func(t *testing.T) {
 		file1, err := ioutil.TempFile("", "hivesim_test")
 		if err != nil {
@@ -214,6 +321,306 @@ func TestStartClientStartOptions(t *testing.T) {
 	})
 }
 
+// This checks volume creation, mounting and removal.
+func TestVolumes(t *testing.T) {
+	var (
+		lastOptions libhive.ContainerOptions
+		created     = make(map[string]map[string]*multipart.FileHeader)
+		removed     []string
+	)
+	tm, srv := newFakeAPI(&fakes.BackendHooks{
+		StartContainer: func(containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error) {
+			lastOptions = opt
+			return &libhive.ContainerInfo{}, nil
+		},
+		CreateVolume: func(name string, files map[string]*multipart.FileHeader) error {
+			created[name] = files
+			return nil
+		},
+		RemoveVolume: func(name string) error {
+			removed = append(removed, name)
+			return nil
+		},
+	})
+	defer srv.Close()
+	defer tm.Terminate()
+
+	fixture, err := ioutil.TempFile("", "hivesim_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(fixture.Name())
+	if _, err := fixture.WriteString("chain"); err != nil {
+		t.Fatal(err)
+	}
+
+	sim := NewAt(srv.URL)
+	suiteID, err := sim.StartSuite("suite", "", "")
+	if err != nil {
+		t.Fatal("can't start suite:", err)
+	}
+	testID, err := sim.StartTest(suiteID, "test", "")
+	if err != nil {
+		t.Fatal("can't start test:", err)
+	}
+
+	// Create a volume with a file.
+	if err := sim.CreateVolume(suiteID, "fixtures", map[string]string{"/chain.rlp": fixture.Name()}); err != nil {
+		t.Fatal("can't create volume:", err)
+	}
+	if len(created) != 1 {
+		t.Fatalf("wrong volumes created: %v", created)
+	}
+	var fixtures string
+	for name, files := range created {
+		fixtures = name
+		if f := files["/chain.rlp"]; f == nil || f.Size != 5 {
+			t.Fatalf("wrong files in volume: %v", files)
+		}
+	}
+	if err := sim.CreateVolume(suiteID, "fixtures", nil); err == nil {
+		t.Fatal("no error for creating existing volume")
+	}
+
+	// Mount it read-only, along with an implicitly created volume.
+	_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1",
+		WithReadOnlyVolume("fixtures", "/fixtures"), WithVolume("datadir", "/data:rw"))
+	if err != nil {
+		t.Fatal("can't start client:", err)
+	}
+	want := []libhive.VolumeMount{
+		{Volume: lastOptions.Volumes[0].Volume, Path: "/data"},
+		{Volume: fixtures, Path: "/fixtures", ReadOnly: true},
+	}
+	if !reflect.DeepEqual(lastOptions.Volumes, want) {
+		t.Fatalf("wrong volume mounts %+v", lastOptions.Volumes)
+	}
+	datadir := want[0].Volume
+
+	// Remove the fixtures volume.
+	if err := sim.RemoveVolume(suiteID, "fixtures"); err != nil {
+		t.Fatal("can't remove volume:", err)
+	}
+	if err := sim.RemoveVolume(suiteID, "fixtures"); err == nil {
+		t.Fatal("no error for removing unknown volume")
+	}
+	if !reflect.DeepEqual(removed, []string{fixtures}) {
+		t.Fatalf("wrong volumes removed: %v", removed)
+	}
+
+	// The remaining volume is removed when the suite ends.
+	if err := sim.EndTest(suiteID, testID, TestResult{Pass: true}); err != nil {
+		t.Fatal("can't end test:", err)
+	}
+	if err := sim.EndSuite(suiteID); err != nil {
+		t.Fatal("can't end suite:", err)
+	}
+	if !reflect.DeepEqual(removed, []string{fixtures, datadir}) {
+		t.Fatalf("wrong volumes removed: %v", removed)
+	}
+}
+
+// This checks that uploaded files can be added to clients.
+func TestUploadedFiles(t *testing.T) {
+	var lastOptions libhive.ContainerOptions
+	tm, srv := newFakeAPI(&fakes.BackendHooks{
+		StartContainer: func(containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error) {
+			lastOptions = opt
+			return &libhive.ContainerInfo{}, nil
+		},
+	})
+	defer srv.Close()
+	defer tm.Terminate()
+
+	sim := NewAt(srv.URL)
+	suiteID, err := sim.StartSuite("suite", "", "")
+	if err != nil {
+		t.Fatal("can't start suite:", err)
+	}
+	testID, err := sim.StartTest(suiteID, "test", "")
+	if err != nil {
+		t.Fatal("can't start test:", err)
+	}
+
+	hash, err := sim.UploadFile(suiteID, strings.NewReader("chain data"))
+	if err != nil {
+		t.Fatal("upload failed:", err)
+	}
+	if want := "827af8214d66adc85b2e3fb9382859fb2b562efbd7193573e70f3ef110b4f3c9"; hash != want {
+		t.Fatalf("wrong hash %q", hash)
+	}
+
+	// Uploaded files override other sources.
+	_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1",
+		WithDynamicFile("/chain.rlp", func() (io.ReadCloser, error) {
+			t.Fatal("this should have been overridden")
+			return nil, nil
+		}),
+		WithUploadedFile("/chain.rlp", hash))
+	if err != nil {
+		t.Fatal("can't start client:", err)
+	}
+	if _, ok := lastOptions.Files["/chain.rlp"]; ok {
+		t.Fatal("overridden file was sent")
+	}
+	cached := lastOptions.CachedFiles["/chain.rlp"]
+	content, err := ioutil.ReadFile(cached)
+	if err != nil {
+		t.Fatal("can't read cached file:", err)
+	}
+	if string(content) != "chain data" {
+		t.Fatalf("wrong cached file content %q", content)
+	}
+
+	// Unknown hashes are rejected.
+	_, _, err = sim.StartClientWithOptions(suiteID, testID, "client-1", WithUploadedFile("/chain.rlp", "00"))
+	if err == nil {
+		t.Fatal("no error for unknown file hash")
+	}
+
+	// The cache is removed when the suite ends.
+	if err := sim.EndTest(suiteID, testID, TestResult{Pass: true}); err != nil {
+		t.Fatal("can't end test:", err)
+	}
+	if err := sim.EndSuite(suiteID); err != nil {
+		t.Fatal("can't end suite:", err)
+	}
+	if _, err := os.Stat(cached); !os.IsNotExist(err) {
+		t.Fatal("cached file not removed at end of suite")
+	}
+}
+
+// This checks reading files from client containers.
+func TestClientFiles(t *testing.T) {
+	tm, srv := newFakeAPI(&fakes.BackendHooks{
+		DownloadFromContainer: func(containerID, path string, w io.Writer) error {
+			switch path {
+			case "/missing":
+				return libhive.ErrPathNotFound
+			case "/keys":
+				tw := tar.NewWriter(w)
+				tw.WriteHeader(&tar.Header{Name: "keys/", Typeflag: tar.TypeDir, Mode: 0755})
+				tw.WriteHeader(&tar.Header{Name: "keys/a", Typeflag: tar.TypeReg, Mode: 0600, Size: 1})
+				tw.Write([]byte("a"))
+				tw.WriteHeader(&tar.Header{Name: "keys/sub/../../../b", Typeflag: tar.TypeReg, Mode: 0600, Size: 1})
+				tw.Write([]byte("b"))
+				return tw.Close()
+			}
+			tw := tar.NewWriter(w)
+			tw.WriteHeader(&tar.Header{Name: filepath.Base(path), Typeflag: tar.TypeReg, Mode: 0644, Size: 2})
+			tw.Write([]byte("{}"))
+			return tw.Close()
+		},
+	})
+	defer srv.Close()
+	defer tm.Terminate()
+
+	dir, err := ioutil.TempDir("", "hivesim_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	suite := Suite{Name: "suite"}
+	suite.Add(TestSpec{
+		Name: "test",
+		Run: func(test *T) {
+			c := test.StartClient("client-1")
+			if content, err := c.ReadFile("/genesis.json"); err != nil || string(content) != "{}" {
+				t.Errorf("wrong file content %q, err %v", content, err)
+			}
+			if _, err := c.ReadFile("/keys"); err == nil {
+				t.Error("no error for reading directory")
+			}
+			if _, err := c.ReadFile("/missing"); err == nil {
+				t.Error("no error for reading missing file")
+			}
+			if err := c.CopyOut("/keys", dir); err != nil {
+				t.Error("copy failed:", err)
+			}
+		},
+	})
+	if err := RunSuite(NewAt(srv.URL), suite); err != nil {
+		t.Fatal("suite run failed:", err)
+	}
+
+	// Check the copied files. The entry which escapes the directory
+	// is written relative to it.
+	for file, want := range map[string]string{"keys/a": "a", "b": "b"} {
+		content, err := ioutil.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Fatalf("wrong content of %s: %q", file, content)
+		}
+	}
+}
+
+// This checks starting and stopping auxiliary service containers.
+func TestServices(t *testing.T) {
+	var (
+		images  = make(map[string]string)
+		deleted []string
+	)
+	tm, srv := newFakeAPI(&fakes.BackendHooks{
+		CreateContainer: func(image string, opt libhive.ContainerOptions) (string, error) {
+			id := fmt.Sprintf("%0.8x", len(images)+1)
+			images[id] = image
+			return id, nil
+		},
+		DeleteContainer: func(containerID string) error {
+			deleted = append(deleted, containerID)
+			return nil
+		},
+	})
+	defer srv.Close()
+	defer tm.Terminate()
+
+	sim := NewAt(srv.URL)
+	suiteID, err := sim.StartSuite("suite", "", "")
+	if err != nil {
+		t.Fatal("can't start suite:", err)
+	}
+
+	if _, _, err := sim.StartService(suiteID, "client-1"); err == nil {
+		t.Fatal("no error for unknown service")
+	}
+	id1, _, err := sim.StartService(suiteID, "dns", Params{"HIVE_DNS_ZONE": "nodes.example.org"})
+	if err != nil {
+		t.Fatal("can't start service:", err)
+	}
+	if images[id1] != "hive/simulators/sim/dns:latest" {
+		t.Fatalf("wrong service image %q", images[id1])
+	}
+	id2, _, err := sim.StartService(suiteID, "dns")
+	if err != nil {
+		t.Fatal("can't start service:", err)
+	}
+
+	// Services can be connected to networks.
+	if err := sim.CreateNetwork(suiteID, "net1"); err != nil {
+		t.Fatal("can't create network:", err)
+	}
+	if err := sim.ConnectContainer(suiteID, "net1", id1); err != nil {
+		t.Fatal("can't connect service:", err)
+	}
+
+	// Stop one service explicitly, the other one is stopped by the end of the suite.
+	if err := sim.StopService(suiteID, id1); err != nil {
+		t.Fatal("can't stop service:", err)
+	}
+	if err := sim.StopService(suiteID, id1); err == nil {
+		t.Fatal("no error for stopping service twice")
+	}
+	if err := sim.EndSuite(suiteID); err != nil {
+		t.Fatal("can't end suite:", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{id1, id2}) {
+		t.Fatalf("wrong containers deleted: %v", deleted)
+	}
+}
+
 // This checks that the simulator can run a program
 func TestRunProgram(t *testing.T) {
 	// Set up the backend to return program execution. Simple debug program here.
@@ -263,6 +670,52 @@ func TestRunProgram(t *testing.T) {
 	}
 }
 
+// This test checks that client hooks run the command declared in the client metadata.
+func TestClientHook(t *testing.T) {
+	var ran []string
+	hooks := &fakes.BackendHooks{
+		RunProgram: func(containerID string, cmd []string) (*libhive.ExecInfo, error) {
+			ran = cmd
+			return &libhive.ExecInfo{Stdout: "done", ExitCode: 0}, nil
+		},
+	}
+	tm, srv := newFakeAPI(hooks)
+	defer srv.Close()
+	defer tm.Terminate()
+
+	sim := NewAt(srv.URL)
+	suiteID, err := sim.StartSuite("suite", "", "")
+	if err != nil {
+		t.Fatal("can't start suite:", err)
+	}
+	testID, err := sim.StartTest(suiteID, "test", "")
+	if err != nil {
+		t.Fatal("can't start test:", err)
+	}
+	clientID, _, err := sim.StartClientWithOptions(suiteID, testID, "client-1")
+	if err != nil {
+		t.Fatal("can't start client:", err)
+	}
+
+	res, err := sim.ClientHook(suiteID, testID, clientID, "reset")
+	if err != nil {
+		t.Fatal("hook failed:", err)
+	}
+	if res.Stdout != "done" {
+		t.Fatalf("wrong hook output %q", res.Stdout)
+	}
+	if want := []string{"/reset.sh", "--full"}; !reflect.DeepEqual(ran, want) {
+		t.Fatalf("wrong hook command %q\nwant %q", ran, want)
+	}
+
+	// Undeclared hooks are an error.
+	if _, err := sim.ClientHook(suiteID, testID, clientID, "peers"); err == nil {
+		t.Fatal("expected error for undeclared hook")
+	} else if !strings.Contains(err.Error(), `has no hook "peers"`) {
+		t.Fatalf("wrong error for undeclared hook: %v", err)
+	}
+}
+
 // This test checks for some common errors returned by StartClient.
 func TestStartClientErrors(t *testing.T) {
 	tm, srv := newFakeAPI(nil)
@@ -300,12 +753,57 @@ func TestStartClientErrors(t *testing.T) {
 	}
 }
 
+// This test checks that API usage is reflected in metrics.
+func TestMetrics(t *testing.T) {
+	metrics := libhive.NewMetrics()
+	tm, srv := newFakeAPIWithMetrics(nil, metrics)
+	defer srv.Close()
+	defer tm.Terminate()
+
+	sim := NewAt(srv.URL)
+	suiteID, err := sim.StartSuite("suite", "", "")
+	if err != nil {
+		t.Fatal("can't start suite:", err)
+	}
+	testID, err := sim.StartTest(suiteID, "test", "")
+	if err != nil {
+		t.Fatal("can't start test:", err)
+	}
+	if _, _, err := sim.StartClientWithOptions(suiteID, testID, "client-1"); err != nil {
+		t.Fatal("can't start client:", err)
+	}
+	if err := sim.EndTest(suiteID, testID, TestResult{Pass: true}); err != nil {
+		t.Fatal("can't end test:", err)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	output := rec.Body.String()
+	for _, want := range []string{
+		`hive_running_suites 1`,
+		`hive_running_tests 0`,
+		`hive_client_start_seconds_count{client="client-1"} 1`,
+		`hive_test_results_total{client="client-1",result="pass",simulator=""} 1`,
+		`hive_api_requests_total{code="200",method="POST",route="/testsuite/{suite}/test/{test}/node"} 1`,
+	} {
+		if !strings.Contains(output, want+"\n") {
+			t.Errorf("metric %q not found in output:\n%s", want, output)
+		}
+	}
+}
+
 func newFakeAPI(hooks *fakes.BackendHooks) (*libhive.TestManager, *httptest.Server) {
+	return newFakeAPIWithMetrics(hooks, nil)
+}
+
+func newFakeAPIWithMetrics(hooks *fakes.BackendHooks, metrics *libhive.Metrics) (*libhive.TestManager, *httptest.Server) {
 	env := libhive.SimEnv{
+		Metrics: metrics,
 		Definitions: map[string]*libhive.ClientDefinition{
-			"client-1": {Name: "client-1", Image: "/ignored/in/api", Version: "client-1-version", Meta: libhive.ClientMetadata{Roles: []string{"eth1"}}},
+			"client-1": {Name: "client-1", Image: "/ignored/in/api", Version: "client-1-version", Meta: libhive.ClientMetadata{Roles: []string{"eth1"}, Hooks: map[string]string{"reset": "/reset.sh --full"}}},
 			"client-2": {Name: "client-2", Image: "/not/exposed/", Version: "client-2-version", Meta: libhive.ClientMetadata{Roles: []string{"beacon"}}},
 		},
+		Services: map[string]string{"dns": "hive/simulators/sim/dns:latest"},
 	}
 	backend := fakes.NewContainerBackend(hooks)
 	tm := libhive.NewTestManager(env, backend, -1)
@@ -1,8 +1,10 @@
 package hivesim
 
 import (
+	"encoding/json"
 	"io"
 	"os"
+	"strconv"
 )
 
 // clientSetup collects client options.
@@ -10,6 +12,10 @@ type clientSetup struct {
 	parameters map[string]string
 	// destination path -> open data function
 	files map[string]func() (io.ReadCloser, error)
+	// destination path -> hash of uploaded file
+	uploads map[string]string
+	// readiness checks, nil if none were requested
+	readiness *ReadinessProbe
 }
 
 // StartOption is a parameter for starting a client.
@@ -32,6 +38,7 @@ func WithStaticFiles(initFiles map[string]string) StartOption {
 	return optionFunc(func(setup *clientSetup) {
 		for k, v := range initFiles {
 			setup.files[k] = fileAsSrc(v)
+			delete(setup.uploads, k)
 		}
 	})
 }
@@ -44,6 +51,22 @@ func WithStaticFiles(initFiles map[string]string) StartOption {
 func WithDynamicFile(dstPath string, src func() (io.ReadCloser, error)) StartOption {
 	return optionFunc(func(setup *clientSetup) {
 		setup.files[dstPath] = src
+		delete(setup.uploads, dstPath)
+	})
+}
+
+// WithUploadedFile adds a file which was uploaded using Simulation.UploadFile to the
+// client. Unlike with WithStaticFiles and WithDynamicFile, the file content is not
+// sent again when starting the client, which is useful for large files.
+//
+// Uploaded files can override other file sources and vice-versa.
+func WithUploadedFile(dstPath, hash string) StartOption {
+	return optionFunc(func(setup *clientSetup) {
+		if setup.uploads == nil {
+			setup.uploads = make(map[string]string)
+		}
+		setup.uploads[dstPath] = hash
+		delete(setup.files, dstPath)
 	})
 }
 
@@ -55,3 +78,108 @@ func Bundle(option ...StartOption) StartOption {
 		}
 	})
 }
+
+// WithMetricsPort makes hive scrape the Prometheus metrics endpoint of the client at
+// the given port and HTTP path while the client is running. If path is empty, it
+// defaults to "/metrics". The samples are stored next to the client log.
+//
+// This sets the HIVE_METRICS_PORT and HIVE_METRICS_PATH parameters.
+func WithMetricsPort(port uint16, path string) StartOption {
+	p := Params{"HIVE_METRICS_PORT": strconv.Itoa(int(port))}
+	if path != "" {
+		p["HIVE_METRICS_PATH"] = path
+	}
+	return p
+}
+
+// WithPacketCapture makes hive record the network traffic of the client container to a
+// pcap file, which is stored next to the client log. Capture can also be enabled for all
+// clients using the --client.pcap flag of hive.
+//
+// This sets the HIVE_PCAP parameter.
+func WithPacketCapture() StartOption {
+	return Params{"HIVE_PCAP": "1"}
+}
+
+// WithVolume mounts the named volume into the client container at the given absolute
+// path. Volumes belong to the test suite: clients started later in the same suite see
+// the data written by earlier clients. Volumes which were not created by
+// Simulation.CreateVolume are created empty. Hive removes the volumes when the suite ends.
+//
+// This sets the HIVE_VOLUME_<name> parameter.
+func WithVolume(name, path string) StartOption {
+	return Params{"HIVE_VOLUME_" + name: path}
+}
+
+// WithReadOnlyVolume is like WithVolume, but mounts the volume read-only. This is
+// useful for sharing fixtures between clients.
+func WithReadOnlyVolume(name, path string) StartOption {
+	return Params{"HIVE_VOLUME_" + name: path + ":ro"}
+}
+
+// ReadinessProbe configures checks which must pass before a started client is
+// considered ready. By default, hive only waits for the client's RPC port to open.
+type ReadinessProbe struct {
+	RPC       *RPCProbe `json:"rpc,omitempty"`
+	Exec      []string  `json:"exec,omitempty"`
+	LogRegexp string    `json:"logRegexp,omitempty"`
+}
+
+// RPCProbe is a readiness check which performs a JSON-RPC call on the client.
+type RPCProbe struct {
+	Port    uint16          `json:"port,omitempty"`
+	Method  string          `json:"method"`
+	Params  []interface{}   `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	AtLeast *uint64         `json:"atLeast,omitempty"`
+}
+
+func (setup *clientSetup) probe() *ReadinessProbe {
+	if setup.readiness == nil {
+		setup.readiness = new(ReadinessProbe)
+	}
+	return setup.readiness
+}
+
+// WaitForRPC makes hive wait until the given JSON-RPC call on port 8545 returns the
+// expected result. If result is nil, any non-error response is accepted.
+//
+// This panics if result cannot be encoded as JSON.
+func WaitForRPC(method string, result interface{}, params ...interface{}) StartOption {
+	probe := &RPCProbe{Method: method, Params: params}
+	if result != nil {
+		enc, err := json.Marshal(result)
+		if err != nil {
+			panic(err)
+		}
+		probe.Result = enc
+	}
+	return optionFunc(func(setup *clientSetup) {
+		setup.probe().RPC = probe
+	})
+}
+
+// WaitForBlockNumber makes hive wait until eth_blockNumber of the client returns
+// a number greater or equal to n.
+func WaitForBlockNumber(n uint64) StartOption {
+	probe := &RPCProbe{Method: "eth_blockNumber", AtLeast: &n}
+	return optionFunc(func(setup *clientSetup) {
+		setup.probe().RPC = probe
+	})
+}
+
+// WaitForExec makes hive wait until the given command exits with code zero when run
+// in the client container. Like ClientExec, the command must be located in /hive-bin.
+func WaitForExec(cmd ...string) StartOption {
+	return optionFunc(func(setup *clientSetup) {
+		setup.probe().Exec = cmd
+	})
+}
+
+// WaitForLog makes hive wait until a line of the client's output matches the
+// given regular expression.
+func WaitForLog(regexp string) StartOption {
+	return optionFunc(func(setup *clientSetup) {
+		setup.probe().LogRegexp = regexp
+	})
+}
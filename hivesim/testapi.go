@@ -1,7 +1,9 @@
 package hivesim
 
 import (
+	"archive/tar"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"runtime"
@@ -120,6 +122,41 @@ func (c *Client) Exec(command ...string) (*ExecInfo, error) {
 	return c.test.Sim.ClientExec(c.test.SuiteID, c.test.TestID, c.Container, command)
 }
 
+// Hook runs a hook declared by the client in its hive.yaml, e.g. "enode" or "reset".
+func (c *Client) Hook(name string) (*ExecInfo, error) {
+	return c.test.Sim.ClientHook(c.test.SuiteID, c.test.TestID, c.Container, name)
+}
+
+// ReadFile returns the content of a file in the client container.
+func (c *Client) ReadFile(path string) ([]byte, error) {
+	archive, err := c.test.Sim.ClientFiles(c.test.SuiteID, c.test.TestID, c.Container, path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	tr := tar.NewReader(archive)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %v", path, err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%s is not a regular file", path)
+	}
+	return ioutil.ReadAll(tr)
+}
+
+// CopyOut copies a file or directory from the client container into the local
+// directory dst, like 'docker cp'. Symbolic links are not copied.
+func (c *Client) CopyOut(path, dst string) error {
+	archive, err := c.test.Sim.ClientFiles(c.test.SuiteID, c.test.TestID, c.Container, path)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	return extractTar(archive, dst)
+}
+
 // T is a running test. This is a lot like testing.T, but has some additional methods for
 // launching clients.
 //
@@ -1,21 +1,26 @@
 package fakes
 
 import (
+	"archive/tar"
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net"
+	"path/filepath"
 
 	"github.com/ethereum/hive/internal/libhive"
 )
 
 // BackendHooks can be used to override the behavior of the fake backend.
 type BackendHooks struct {
-	CreateContainer func(image string, opt libhive.ContainerOptions) (string, error)
-	StartContainer  func(containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error)
-	DeleteContainer func(containerID string) error
-	RunEnodeSh      func(containerID string) (string, error)
-	RunProgram      func(containerID string, cmd []string) (*libhive.ExecInfo, error)
+	CreateContainer       func(image string, opt libhive.ContainerOptions) (string, error)
+	StartContainer        func(containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error)
+	DeleteContainer       func(containerID string) error
+	RunEnodeSh            func(containerID string) (string, error)
+	RunProgram            func(containerID string, cmd []string) (*libhive.ExecInfo, error)
+	DownloadFromContainer func(containerID, path string, w io.Writer) error
 
 	NetworkNameToID     func(string) (string, error)
 	CreateNetwork       func(string) (string, error)
@@ -23,6 +28,9 @@ type BackendHooks struct {
 	ContainerIP         func(containerID, networkID string) (net.IP, error)
 	ConnectContainer    func(containerID, networkID string) error
 	DisconnectContainer func(containerID, networkID string) error
+
+	CreateVolume func(name string, files map[string]*multipart.FileHeader) error
+	RemoveVolume func(name string) error
 }
 
 var _ = libhive.ContainerBackend(&fakeBackend{})
@@ -99,6 +107,18 @@ func (b *fakeBackend) RunProgram(ctx context.Context, containerID string, cmd []
 	return &libhive.ExecInfo{Stdout: "std output", Stderr: "std err", ExitCode: 0}, nil
 }
 
+func (b *fakeBackend) DownloadFromContainer(ctx context.Context, containerID, path string, w io.Writer) error {
+	if b.hooks.DownloadFromContainer != nil {
+		return b.hooks.DownloadFromContainer(containerID, path, w)
+	}
+	// By default, the path is a file.
+	content := []byte("file content")
+	tw := tar.NewWriter(w)
+	tw.WriteHeader(&tar.Header{Name: filepath.Base(path), Mode: 0644, Size: int64(len(content))})
+	tw.Write(content)
+	return tw.Close()
+}
+
 func (b *fakeBackend) NetworkNameToID(name string) (string, error) {
 	if b.hooks.NetworkNameToID != nil {
 		return b.hooks.NetworkNameToID(name)
@@ -142,3 +162,17 @@ func (b *fakeBackend) DisconnectContainer(containerID, networkID string) error {
 	}
 	return nil
 }
+
+func (b *fakeBackend) CreateVolume(ctx context.Context, name string, files map[string]*multipart.FileHeader) error {
+	if b.hooks.CreateVolume != nil {
+		return b.hooks.CreateVolume(name, files)
+	}
+	return nil
+}
+
+func (b *fakeBackend) RemoveVolume(name string) error {
+	if b.hooks.RemoveVolume != nil {
+		return b.hooks.RemoveVolume(name)
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ethereum/hive/internal/libhive"
 	docker "github.com/fsouza/go-dockerclient"
@@ -51,20 +52,78 @@ func (b *Builder) ReadClientMetadata(name string) (*libhive.ClientMetadata, erro
 	return &out, nil
 }
 
+// ReadSimulatorMetadata reads metadata of the given simulator.
+func (b *Builder) ReadSimulatorMetadata(name string) (*libhive.SimulatorMetadata, error) {
+	dir := b.config.Inventory.SimulatorDirectory(name)
+	f, err := os.Open(filepath.Join(dir, "hive.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &libhive.SimulatorMetadata{}, nil
+		}
+		return nil, fmt.Errorf("failed to read hive metadata file in '%s': %v", dir, err)
+	}
+	defer f.Close()
+	var out libhive.SimulatorMetadata
+	if err := yaml.NewDecoder(f).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode hive metadata file in '%s': %v", dir, err)
+	}
+	// Service names are used in image tags and must be lowercase. This also ensures
+	// that the inventory does not find service Dockerfiles before the simulator's.
+	for _, service := range out.Services {
+		if service == "" || service != filepath.Base(service) || strings.HasPrefix(service, ".") || service != strings.ToLower(service) {
+			return nil, fmt.Errorf("invalid service name %q in '%s'", service, dir)
+		}
+	}
+	return &out, nil
+}
+
 // BuildClientImage builds a docker image of the given client.
 func (b *Builder) BuildClientImage(ctx context.Context, name string) (string, error) {
 	dir := b.config.Inventory.ClientDirectory(name)
 	_, branch := libhive.SplitClientName(name)
 	tag := fmt.Sprintf("hive/clients/%s:latest", name)
-	err := b.buildImage(ctx, dir, branch, tag)
+	err := b.buildImage(ctx, dir, "Dockerfile", branch, tag)
 	return tag, err
 }
 
 // BuildSimulatorImage builds a docker image of a simulator.
 func (b *Builder) BuildSimulatorImage(ctx context.Context, name string) (string, error) {
+	meta, err := b.ReadSimulatorMetadata(name)
+	if err != nil {
+		return "", err
+	}
 	dir := b.config.Inventory.SimulatorDirectory(name)
+	buildContext, dockerfile, err := simulatorBuildContext(dir, meta.BuildContext)
+	if err != nil {
+		return "", err
+	}
 	tag := fmt.Sprintf("hive/simulators/%s:latest", name)
-	err := b.buildImage(ctx, dir, "", tag)
+	err = b.buildImage(ctx, buildContext, dockerfile, "", tag)
+	return tag, err
+}
+
+// simulatorBuildContext resolves the build context of a simulator. It returns the
+// context directory and the path of the simulator's Dockerfile within the context.
+func simulatorBuildContext(dir, buildContext string) (string, string, error) {
+	if buildContext == "" {
+		return dir, "Dockerfile", nil
+	}
+	if filepath.IsAbs(buildContext) {
+		return "", "", fmt.Errorf("build context %q of '%s' is not a relative path", buildContext, dir)
+	}
+	contextDir := filepath.Join(dir, buildContext)
+	rel, err := filepath.Rel(contextDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("build context %q does not contain '%s'", buildContext, dir)
+	}
+	return contextDir, filepath.ToSlash(filepath.Join(rel, "Dockerfile")), nil
+}
+
+// BuildServiceImage builds a docker image of an auxiliary service of a simulator.
+func (b *Builder) BuildServiceImage(ctx context.Context, sim, service string) (string, error) {
+	dir := b.config.Inventory.ServiceDirectory(sim, service)
+	tag := fmt.Sprintf("hive/simulators/%s/%s:latest", sim, service)
+	err := b.buildImage(ctx, dir, "Dockerfile", "", tag)
 	return tag, err
 }
 
@@ -109,8 +168,9 @@ func (b *Builder) ReadFile(image, path string) ([]byte, error) {
 }
 
 // buildImage builds a single docker image from the specified context.
+// dockerfile is the path of the Dockerfile within the context.
 // branch specifes a build argument to use a specific base image branch or github source branch.
-func (b *Builder) buildImage(ctx context.Context, contextDir, branch, imageTag string) error {
+func (b *Builder) buildImage(ctx context.Context, contextDir, dockerfile, branch, imageTag string) error {
 	nocache := false
 	if b.config.NoCachePattern != nil {
 		nocache = b.config.NoCachePattern.MatchString(imageTag)
@@ -127,7 +187,7 @@ func (b *Builder) buildImage(ctx context.Context, contextDir, branch, imageTag s
 		Name:         imageTag,
 		ContextDir:   context,
 		OutputStream: ioutil.Discard,
-		Dockerfile:   "Dockerfile",
+		Dockerfile:   dockerfile,
 		NoCache:      nocache,
 		Pull:         b.config.PullEnabled,
 	}
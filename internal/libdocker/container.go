@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"mime/multipart"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"sync"
@@ -24,10 +25,23 @@ type ContainerBackend struct {
 	client *docker.Client
 	config *Config
 	logger log15.Logger
+
+	// The packet capture image is built by BuildPcapImage. A failed build is
+	// retried on the next capture request.
+	pcapMu    sync.Mutex
+	pcapBuilt bool
+
+	// Captures of running containers, by ID of the captured container.
+	captureMu sync.Mutex
+	captures  map[string]*capture
+
+	// The volume image is built on demand.
+	volumeOnce sync.Once
+	volumeErr  error
 }
 
 func NewContainerBackend(c *docker.Client, cfg *Config) *ContainerBackend {
-	b := &ContainerBackend{client: c, config: cfg, logger: cfg.Logger}
+	b := &ContainerBackend{client: c, config: cfg, logger: cfg.Logger, captures: make(map[string]*capture)}
 	if b.logger == nil {
 		b.logger = log15.Root()
 	}
@@ -100,20 +114,50 @@ func (b *ContainerBackend) CreateContainer(ctx context.Context, imageName string
 	for key, val := range opt.Env {
 		vars = append(vars, key+"="+val)
 	}
-	c, err := b.client.CreateContainer(docker.CreateContainerOptions{
+	createOpts := docker.CreateContainerOptions{
 		Context: ctx,
 		Config: &docker.Config{
 			Image: imageName,
 			Env:   vars,
 		},
-	})
+		HostConfig: &docker.HostConfig{},
+	}
+	if len(opt.Volumes) > 0 {
+		// Named volumes are created by docker if they don't exist yet.
+		mounts := make([]docker.HostMount, len(opt.Volumes))
+		for i, v := range opt.Volumes {
+			mounts[i] = docker.HostMount{Type: "volume", Source: v.Volume, Target: v.Path, ReadOnly: v.ReadOnly}
+		}
+		createOpts.HostConfig.Mounts = mounts
+	}
+
+	// Start capturing network traffic if requested. The container is created in the
+	// network namespace of the capture container. Capture failures are not fatal, the
+	// container just runs without it.
+	var pcap *capture
+	if opt.Capture {
+		var err error
+		if pcap, err = b.startCapture(ctx); err != nil {
+			b.logger.Error("could not start packet capture", "image", imageName, "err", err)
+		} else {
+			createOpts.HostConfig.NetworkMode = "container:" + pcap.id
+		}
+	}
+
+	c, err := b.client.CreateContainer(createOpts)
 	if err != nil {
+		if pcap != nil {
+			b.stopCapture(pcap)
+		}
 		return "", err
 	}
 	logger := b.logger.New("image", imageName, "container", c.ID[:8])
+	if pcap != nil {
+		b.addCapture(c.ID, pcap)
+	}
 
 	// Now upload files.
-	if err := b.uploadFiles(ctx, c.ID, opt.Files); err != nil {
+	if err := b.uploadFiles(ctx, c.ID, opt.Files, opt.CachedFiles); err != nil {
 		logger.Error("container file upload failed", "err", err)
 		b.DeleteContainer(c.ID)
 		return "", err
@@ -122,6 +166,19 @@ func (b *ContainerBackend) CreateContainer(ctx context.Context, imageName string
 	return c.ID, err
 }
 
+// DownloadFromContainer writes a tar archive of a path in the container to w.
+func (b *ContainerBackend) DownloadFromContainer(ctx context.Context, containerID, path string, w io.Writer) error {
+	err := b.client.DownloadFromContainer(containerID, docker.DownloadFromContainerOptions{
+		Context:      ctx,
+		Path:         path,
+		OutputStream: w,
+	})
+	if derr, ok := err.(*docker.Error); ok && derr.Status == http.StatusNotFound {
+		return libhive.ErrPathNotFound
+	}
+	return err
+}
+
 // StartContainer starts a docker container.
 func (b *ContainerBackend) StartContainer(ctx context.Context, containerID string, opt libhive.ContainerOptions) (*libhive.ContainerInfo, error) {
 	info := &libhive.ContainerInfo{ID: containerID[:8], LogFile: opt.LogFile}
@@ -135,6 +192,11 @@ func (b *ContainerBackend) StartContainer(ctx context.Context, containerID strin
 		return nil, fmt.Errorf("container did not start: %v", err)
 	}
 
+	// The pcap file is written when the container exits.
+	if b.captureStarted(containerID, opt.CaptureFile) && opt.CaptureFile != "" {
+		info.CaptureFile = opt.CaptureFile
+	}
+
 	// This goroutine waits for the container to end and closes log
 	// files when done.
 	containerExit := make(chan struct{})
@@ -143,18 +205,23 @@ func (b *ContainerBackend) StartContainer(ctx context.Context, containerID strin
 		err := waiter.Wait()
 		waiter.Close()
 		logger.Debug("container exited", "err", err)
+		b.endCapture(containerID, false)
 	}()
 	// Set up the wait function.
 	info.Wait = func() { <-containerExit }
+	// Sample resource usage while the container runs.
+	info.Usage = b.collectStats(logger, containerID)
 
 	// Get the IP. This can only be done after the container has started.
-	inspect := docker.InspectContainerOptions{Context: ctx, ID: containerID}
+	inspect := docker.InspectContainerOptions{Context: ctx, ID: b.networkContainer(containerID)}
 	container, err := b.client.InspectContainerWithOptions(inspect)
 	if err != nil {
 		waiter.Close()
 		b.DeleteContainer(containerID)
 		info.Wait()
 		info.Wait = nil
+		info.Usage()
+		info.Usage = nil
 		return info, err
 	}
 	info.IP = container.NetworkSettings.IPAddress
@@ -181,10 +248,18 @@ func (b *ContainerBackend) StartContainer(ctx context.Context, containerID strin
 	case <-ctx.Done():
 		checkErr = errors.New("timed out waiting for container startup")
 	}
+	if checkErr == nil && opt.Readiness != nil {
+		checkErr = b.waitReady(ctx, logger, info, opt.Readiness, containerExit)
+		if checkErr == nil {
+			logger.Debug("container ready", "time", time.Since(startTime))
+		}
+	}
 	if checkErr != nil {
 		b.DeleteContainer(containerID)
 		info.Wait()
 		info.Wait = nil
+		info.Usage()
+		info.Usage = nil
 	}
 	return info, checkErr
 }
@@ -223,6 +298,8 @@ func (b *ContainerBackend) DeleteContainer(containerID string) error {
 	if err != nil {
 		b.logger.Error("can't remove container", "container", containerID[:8], "err", err)
 	}
+	// The capture of a started container ends when the container exits.
+	b.endCapture(containerID, true)
 	return err
 }
 
@@ -271,7 +348,7 @@ func (b *ContainerBackend) RemoveNetwork(id string) error {
 // ContainerIP finds the IP of a container in the given network.
 func (b *ContainerBackend) ContainerIP(containerID, networkID string) (net.IP, error) {
 	details, err := b.client.InspectContainerWithOptions(docker.InspectContainerOptions{
-		ID: containerID,
+		ID: b.networkContainer(containerID),
 	})
 	if err != nil {
 		return nil, err
@@ -288,61 +365,80 @@ func (b *ContainerBackend) ContainerIP(containerID, networkID string) (net.IP, e
 // ConnectContainer connects the given container to a network.
 func (b *ContainerBackend) ConnectContainer(containerID, networkID string) error {
 	return b.client.ConnectNetwork(networkID, docker.NetworkConnectionOptions{
-		Container: containerID,
+		Container: b.networkContainer(containerID),
 	})
 }
 
 // DisconnectContainer disconnects the given container from a network.
 func (b *ContainerBackend) DisconnectContainer(containerID, networkID string) error {
 	return b.client.DisconnectNetwork(networkID, docker.NetworkConnectionOptions{
-		Container: containerID,
+		Container: b.networkContainer(containerID),
 	})
 }
 
-// uploadFiles uploads the given files into a docker container.
-func (b *ContainerBackend) uploadFiles(ctx context.Context, id string, files map[string]*multipart.FileHeader) error {
+// uploadFiles copies files into a container. The tar archive is streamed to docker,
+// so files are never held in memory.
+func (b *ContainerBackend) uploadFiles(ctx context.Context, id string, files map[string]*multipart.FileHeader, cachedFiles map[string]string) error {
 	// Short circuit if there are no files to upload
-	if len(files) == 0 {
+	if len(files) == 0 && len(cachedFiles) == 0 {
 		return nil
 	}
-	// Create a tarball archive with all the data files
-	tarball := new(bytes.Buffer)
-	tw := tar.NewWriter(tarball)
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTarball(pw, files, cachedFiles))
+	}()
+	err := b.client.UploadToContainer(id, docker.UploadToContainerOptions{
+		Context:     ctx,
+		InputStream: pr,
+		Path:        "/",
+	})
+	// Unblock the writer if the upload ended early.
+	pr.Close()
+	return err
+}
+
+// writeTarball writes a tar archive containing the given files to w.
+func writeTarball(w io.Writer, files map[string]*multipart.FileHeader, cachedFiles map[string]string) error {
+	tw := tar.NewWriter(w)
 	for filePath, fileHeader := range files {
-		// Fetch the next file to inject into the container
 		file, err := fileHeader.Open()
 		if err != nil {
 			return err
 		}
-		defer file.Close()
-
-		data, err := ioutil.ReadAll(file)
+		err = writeTarFile(tw, filePath, file, fileHeader.Size)
+		file.Close()
 		if err != nil {
 			return err
 		}
-		// Insert the file into the tarball archive
-		header := &tar.Header{
-			Name: filePath,
-			Mode: int64(0777),
-			Size: int64(len(data)),
-		}
-		if err := tw.WriteHeader(header); err != nil {
+	}
+	for filePath, src := range cachedFiles {
+		file, err := os.Open(src)
+		if err != nil {
 			return err
 		}
-		if _, err := tw.Write(data); err != nil {
+		stat, err := file.Stat()
+		if err == nil {
+			err = writeTarFile(tw, filePath, file, stat.Size())
+		}
+		file.Close()
+		if err != nil {
 			return err
 		}
 	}
-	if err := tw.Close(); err != nil {
+	return tw.Close()
+}
+
+func writeTarFile(tw *tar.Writer, name string, r io.Reader, size int64) error {
+	header := &tar.Header{
+		Name: name,
+		Mode: int64(0777),
+		Size: size,
+	}
+	if err := tw.WriteHeader(header); err != nil {
 		return err
 	}
-
-	// Upload the tarball into the destination container
-	return b.client.UploadToContainer(id, docker.UploadToContainerOptions{
-		Context:     ctx,
-		InputStream: tarball,
-		Path:        "/",
-	})
+	_, err := io.Copy(tw, r)
+	return err
 }
 
 // runContainer attaches to the output streams of an existing container, then
@@ -480,3 +576,30 @@ func (w *linePrefixWriter) Close() error {
 	w.buf = nil
 	return err
 }
+
+// buildHelperImage builds an image from the given Dockerfile. Helper images are
+// used for auxiliary containers started by hive itself.
+func (b *ContainerBackend) buildHelperImage(ctx context.Context, tag, dockerfile string) error {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	w.WriteHeader(&tar.Header{Name: "Dockerfile", Mode: 0644, Size: int64(len(dockerfile))})
+	w.Write([]byte(dockerfile))
+	w.Close()
+
+	b.logger.Info("building image", "image", tag)
+	opts := docker.BuildImageOptions{
+		Context:      ctx,
+		Name:         tag,
+		InputStream:  &buf,
+		OutputStream: ioutil.Discard,
+		Pull:         b.config.PullEnabled,
+	}
+	if b.config.BuildOutput != nil {
+		opts.OutputStream = b.config.BuildOutput
+	}
+	err := b.client.BuildImage(opts)
+	if err != nil {
+		b.logger.Error("image build failed", "image", tag, "err", err)
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package libdocker
+
+import (
+	"archive/tar"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// This image runs tcpdump. When packet capture is enabled for all clients, it is built
+// before any simulation runs, so building it does not count against client start
+// timeouts. Otherwise it is built when a client first requests packet capture.
+const (
+	pcapImage      = "hive/pcap:latest"
+	pcapDockerfile = `FROM alpine:latest
+RUN apk add --no-cache tcpdump
+ENTRYPOINT ["tcpdump"]
+`
+	// pcapPath is the capture file in the capture container.
+	pcapPath = "/capture.pcap"
+)
+
+// capture is a packet capture container. It owns the network namespace of the captured
+// container, which joins the namespace when it is created.
+type capture struct {
+	id      string // ID of the capture container
+	file    string // destination of the pcap file, set when the captured container starts
+	started bool   // whether the captured container has started
+}
+
+// BuildPcapImage builds the packet capture image if it was not built yet.
+func (b *ContainerBackend) BuildPcapImage(ctx context.Context) error {
+	b.pcapMu.Lock()
+	defer b.pcapMu.Unlock()
+	if b.pcapBuilt {
+		return nil
+	}
+	if err := b.buildHelperImage(ctx, pcapImage, pcapDockerfile); err != nil {
+		return err
+	}
+	b.pcapBuilt = true
+	return nil
+}
+
+// startCapture runs tcpdump in a new network namespace. Since the capture is running
+// before the captured container is created, no packets of the container are missed.
+func (b *ContainerBackend) startCapture(ctx context.Context) (*capture, error) {
+	if err := b.BuildPcapImage(ctx); err != nil {
+		return nil, err
+	}
+
+	// Capture on all interfaces because the container can be connected
+	// to additional networks while it runs. -U writes packets unbuffered.
+	// tcpdump must keep running as root to write the file.
+	c, err := b.client.CreateContainer(docker.CreateContainerOptions{
+		Context: ctx,
+		Config: &docker.Config{
+			Image: pcapImage,
+			Cmd:   []string{"-i", "any", "-U", "-Z", "root", "-w", pcapPath},
+		},
+		HostConfig: &docker.HostConfig{
+			CapAdd: []string{"NET_ADMIN", "NET_RAW"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := b.client.StartContainerWithContext(c.ID, nil, ctx); err != nil {
+		b.client.RemoveContainer(docker.RemoveContainerOptions{ID: c.ID, Force: true})
+		return nil, err
+	}
+	b.logger.Debug("started packet capture", "capture", c.ID[:8])
+	return &capture{id: c.ID}, nil
+}
+
+// stopCapture stops the capture container, saves the pcap file and removes the
+// container. This must only be called when the captured container is not running.
+func (b *ContainerBackend) stopCapture(c *capture) {
+	logger := b.logger.New("capture", c.id[:8])
+
+	// Stopping sends SIGTERM, which makes tcpdump flush its output.
+	if err := b.client.StopContainer(c.id, 5); err != nil {
+		logger.Debug("could not stop packet capture", "err", err)
+	}
+	if c.file != "" {
+		if err := b.saveCapture(c.id, c.file); err != nil {
+			logger.Error("could not save packet capture", "file", c.file, "err", err)
+		} else {
+			logger.Debug("packet capture ended", "file", c.file)
+		}
+	}
+	b.client.RemoveContainer(docker.RemoveContainerOptions{ID: c.id, Force: true})
+}
+
+// saveCapture copies the pcap file out of the capture container.
+func (b *ContainerBackend) saveCapture(containerID, file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// The file is downloaded as a tar archive.
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(b.client.DownloadFromContainer(containerID, docker.DownloadFromContainerOptions{
+			Path:         pcapPath,
+			OutputStream: pw,
+		}))
+	}()
+	archive := tar.NewReader(pr)
+	if _, err := archive.Next(); err != nil {
+		return err
+	}
+	_, err = io.Copy(out, archive)
+	return err
+}
+
+// addCapture registers the capture of a container.
+func (b *ContainerBackend) addCapture(containerID string, c *capture) {
+	b.captureMu.Lock()
+	defer b.captureMu.Unlock()
+	b.captures[containerID] = c
+}
+
+// captureStarted records that the captured container has started. The pcap file is
+// saved to the given file when the capture ends. It returns false if the container
+// is not captured.
+func (b *ContainerBackend) captureStarted(containerID, file string) bool {
+	b.captureMu.Lock()
+	defer b.captureMu.Unlock()
+	c := b.captures[containerID]
+	if c == nil {
+		return false
+	}
+	c.started, c.file = true, file
+	return true
+}
+
+// endCapture stops the capture of a container. If onlyUnstarted is set, the capture
+// is only stopped if the captured container was never started.
+func (b *ContainerBackend) endCapture(containerID string, onlyUnstarted bool) {
+	b.captureMu.Lock()
+	c := b.captures[containerID]
+	if c == nil || (onlyUnstarted && c.started) {
+		b.captureMu.Unlock()
+		return
+	}
+	delete(b.captures, containerID)
+	b.captureMu.Unlock()
+	b.stopCapture(c)
+}
+
+// networkContainer returns the container which owns the network namespace of the
+// given container. Network operations must use this container.
+func (b *ContainerBackend) networkContainer(containerID string) string {
+	b.captureMu.Lock()
+	defer b.captureMu.Unlock()
+	if c := b.captures[containerID]; c != nil {
+		return c.id
+	}
+	return containerID
+}
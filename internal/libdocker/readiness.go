@@ -0,0 +1,189 @@
+package libdocker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/hive/internal/libhive"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// waitReady runs the readiness checks of a started container until all of them pass.
+func (b *ContainerBackend) waitReady(ctx context.Context, logger log15.Logger, info *libhive.ContainerInfo, probe *libhive.ReadinessProbe, exited <-chan struct{}) error {
+	var checks []func() (bool, error)
+	if probe.RPC != nil {
+		addr := fmt.Sprintf("http://%s:%d", info.IP, rpcPort(probe.RPC))
+		checks = append(checks, func() (bool, error) {
+			return checkRPC(ctx, addr, probe.RPC)
+		})
+	}
+	if len(probe.Exec) > 0 {
+		checks = append(checks, func() (bool, error) {
+			res, err := b.RunProgram(ctx, info.ID, probe.Exec)
+			return err == nil && res.ExitCode == 0, nil
+		})
+	}
+	if probe.LogRegexp != "" {
+		lc, err := newLogChecker(info.LogFile, probe.LogRegexp)
+		if err != nil {
+			return err
+		}
+		defer lc.close()
+		checks = append(checks, lc.check)
+	}
+
+	var (
+		lastMsg time.Time
+		ticker  = time.NewTicker(100 * time.Millisecond)
+	)
+	defer ticker.Stop()
+	for len(checks) > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.New("timed out waiting for container readiness")
+		case <-exited:
+			return errors.New("terminated unexpectedly")
+		case <-ticker.C:
+			if time.Since(lastMsg) >= time.Second {
+				logger.Debug("checking container ready...")
+				lastMsg = time.Now()
+			}
+			// Checks which passed are not run again.
+			remaining := checks[:0]
+			for _, check := range checks {
+				ok, err := check()
+				if err != nil {
+					return err
+				}
+				if !ok {
+					remaining = append(remaining, check)
+				}
+			}
+			checks = remaining
+		}
+	}
+	return nil
+}
+
+func rpcPort(p *libhive.RPCProbe) uint16 {
+	if p.Port == 0 {
+		return 8545
+	}
+	return p.Port
+}
+
+// checkRPC performs the JSON-RPC call of the probe and checks the result.
+func checkRPC(ctx context.Context, url string, p *libhive.RPCProbe) (bool, error) {
+	params := p.Params
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  p.Method,
+		"params":  params,
+	})
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("content-type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, nil // not up yet
+	}
+	defer resp.Body.Close()
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil || response.Error != nil {
+		return false, nil
+	}
+	return rpcResultMatches(response.Result, p), nil
+}
+
+// rpcResultMatches reports whether an RPC result satisfies the probe.
+func rpcResultMatches(result json.RawMessage, p *libhive.RPCProbe) bool {
+	switch {
+	case p.AtLeast != nil:
+		var hex string
+		if err := json.Unmarshal(result, &hex); err != nil || !strings.HasPrefix(hex, "0x") {
+			return false
+		}
+		n, err := strconv.ParseUint(hex[2:], 16, 64)
+		return err == nil && n >= *p.AtLeast
+	case p.Result != nil:
+		var want, got interface{}
+		if json.Unmarshal(p.Result, &want) != nil || json.Unmarshal(result, &got) != nil {
+			return false
+		}
+		return reflect.DeepEqual(want, got)
+	default:
+		return true
+	}
+}
+
+// logChecker matches lines of a container log file against a regular expression.
+type logChecker struct {
+	file    *os.File
+	reader  *bufio.Reader
+	re      *regexp.Regexp
+	partial []byte
+}
+
+func newLogChecker(file, expr string) (*logChecker, error) {
+	if file == "" {
+		return nil, errors.New("readiness: log check requires a container log file")
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	return &logChecker{file: f, reader: bufio.NewReader(f), re: re}, nil
+}
+
+// check reads the lines written since the last call and reports whether
+// any of them matched.
+func (lc *logChecker) check() (bool, error) {
+	for {
+		line, err := lc.reader.ReadBytes('\n')
+		lc.partial = append(lc.partial, line...)
+		if err == io.EOF {
+			return false, nil // incomplete line, wait for more output
+		} else if err != nil {
+			return false, err
+		}
+		if lc.re.Match(bytes.TrimRight(lc.partial, "\r\n")) {
+			return true, nil
+		}
+		lc.partial = lc.partial[:0]
+	}
+}
+
+func (lc *logChecker) close() {
+	lc.file.Close()
+}
@@ -0,0 +1,162 @@
+package libdocker
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ethereum/hive/internal/libhive"
+	docker "github.com/fsouza/go-dockerclient"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// collectStats samples docker stats of a running container. The returned function
+// ends sampling and returns the usage summary.
+func (b *ContainerBackend) collectStats(logger log15.Logger, containerID string) func() *libhive.ResourceUsage {
+	var (
+		stats    = make(chan *docker.Stats)
+		done     = make(chan bool)
+		finished = make(chan struct{})
+		acc      usageAccumulator
+	)
+	go func() {
+		opt := docker.StatsOptions{ID: containerID, Stats: stats, Stream: true, Done: done}
+		if err := b.client.Stats(opt); err != nil {
+			logger.Debug("container stats ended", "err", err)
+		}
+	}()
+	go func() {
+		defer close(finished)
+		// The Stats method closes the channel when it returns.
+		for s := range stats {
+			acc.add(s)
+		}
+	}()
+
+	var once sync.Once
+	return func() *libhive.ResourceUsage {
+		once.Do(func() { close(done) })
+		<-finished
+		return acc.result()
+	}
+}
+
+// usageAccumulator computes peak and average values of docker stats samples.
+type usageAccumulator struct {
+	prev                  *docker.Stats
+	samples               int
+	cpu, mem              usageStat
+	netRx, netTx          usageStat
+	blockRead, blockWrite usageStat
+}
+
+func (acc *usageAccumulator) add(s *docker.Stats) {
+	// Docker sends an empty sample when the container has stopped.
+	if s.Read.IsZero() {
+		return
+	}
+	acc.samples++
+	acc.mem.add(float64(memoryRSS(s)))
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemCPUUsage) - float64(s.PreCPUStats.SystemCPUUsage)
+	if cpuDelta >= 0 && systemDelta > 0 {
+		cpus := float64(s.CPUStats.OnlineCPUs)
+		if cpus == 0 {
+			cpus = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+		}
+		acc.cpu.add(cpuDelta / systemDelta * cpus * 100)
+	}
+
+	// I/O counters are cumulative, rates are computed from the previous sample.
+	if acc.prev != nil {
+		if secs := s.Read.Sub(acc.prev.Read).Seconds(); secs > 0 {
+			rx, tx := networkBytes(s)
+			prx, ptx := networkBytes(acc.prev)
+			acc.netRx.add(rate(prx, rx, secs))
+			acc.netTx.add(rate(ptx, tx, secs))
+			read, write := blockBytes(s)
+			pread, pwrite := blockBytes(acc.prev)
+			acc.blockRead.add(rate(pread, read, secs))
+			acc.blockWrite.add(rate(pwrite, write, secs))
+		}
+	}
+	acc.prev = s
+}
+
+func (acc *usageAccumulator) result() *libhive.ResourceUsage {
+	if acc.samples == 0 {
+		return nil
+	}
+	return &libhive.ResourceUsage{
+		Samples:    acc.samples,
+		CPUPercent: acc.cpu.value(),
+		MemoryRSS:  acc.mem.value(),
+		NetRx:      acc.netRx.value(),
+		NetTx:      acc.netTx.value(),
+		BlockRead:  acc.blockRead.value(),
+		BlockWrite: acc.blockWrite.value(),
+	}
+}
+
+type usageStat struct {
+	peak, sum float64
+	n         int
+}
+
+func (st *usageStat) add(v float64) {
+	if v > st.peak {
+		st.peak = v
+	}
+	st.sum += v
+	st.n++
+}
+
+func (st *usageStat) value() libhive.UsageStat {
+	if st.n == 0 {
+		return libhive.UsageStat{}
+	}
+	return libhive.UsageStat{Peak: st.peak, Avg: st.sum / float64(st.n)}
+}
+
+func rate(prev, cur uint64, secs float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / secs
+}
+
+// memoryRSS returns the resident memory of the container. On cgroup v2 hosts,
+// the RSS counter is unavailable and usage minus file cache is returned instead.
+func memoryRSS(s *docker.Stats) uint64 {
+	m := s.MemoryStats
+	if m.Stats.Rss != 0 {
+		return m.Stats.Rss
+	}
+	inactive := m.Stats.InactiveFile
+	if m.Stats.TotalInactiveFile != 0 {
+		inactive = m.Stats.TotalInactiveFile
+	}
+	if inactive > m.Usage {
+		return 0
+	}
+	return m.Usage - inactive
+}
+
+func networkBytes(s *docker.Stats) (rx, tx uint64) {
+	for _, n := range s.Networks {
+		rx += n.RxBytes
+		tx += n.TxBytes
+	}
+	return rx, tx
+}
+
+func blockBytes(s *docker.Stats) (read, write uint64) {
+	for _, e := range s.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			read += e.Value
+		case "write":
+			write += e.Value
+		}
+	}
+	return read, write
+}
@@ -0,0 +1,79 @@
+package libdocker
+
+import (
+	"context"
+	"mime/multipart"
+	"path"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// This image is used to copy files into volumes. It is built when the first volume
+// with files is created. Containers of this image are never started.
+const (
+	volumeImage      = "hive/volume:latest"
+	volumeDockerfile = `FROM alpine:latest
+`
+	volumeMountPath = "/volume"
+)
+
+// buildVolumeImage builds the volume image.
+func (b *ContainerBackend) buildVolumeImage(ctx context.Context) error {
+	b.volumeOnce.Do(func() {
+		b.volumeErr = b.buildHelperImage(ctx, volumeImage, volumeDockerfile)
+	})
+	return b.volumeErr
+}
+
+// CreateVolume creates a named volume containing the given files.
+func (b *ContainerBackend) CreateVolume(ctx context.Context, name string, files map[string]*multipart.FileHeader) error {
+	_, err := b.client.CreateVolume(docker.CreateVolumeOptions{Context: ctx, Name: name})
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	if err := b.fillVolume(ctx, name, files); err != nil {
+		b.logger.Error("volume file upload failed", "volume", name, "err", err)
+		b.RemoveVolume(name)
+		return err
+	}
+	b.logger.Debug("volume created", "volume", name, "files", len(files))
+	return nil
+}
+
+// fillVolume uploads files into a volume through a container which mounts it.
+func (b *ContainerBackend) fillVolume(ctx context.Context, name string, files map[string]*multipart.FileHeader) error {
+	if err := b.buildVolumeImage(ctx); err != nil {
+		return err
+	}
+	c, err := b.client.CreateContainer(docker.CreateContainerOptions{
+		Context: ctx,
+		Config:  &docker.Config{Image: volumeImage},
+		HostConfig: &docker.HostConfig{
+			Mounts: []docker.HostMount{{Type: "volume", Source: name, Target: volumeMountPath}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer b.client.RemoveContainer(docker.RemoveContainerOptions{ID: c.ID, Force: true})
+
+	// File paths are relative to the volume root.
+	volumeFiles := make(map[string]*multipart.FileHeader, len(files))
+	for p, fh := range files {
+		volumeFiles[path.Join(volumeMountPath, path.Clean("/"+p))] = fh
+	}
+	return b.uploadFiles(ctx, c.ID, volumeFiles, nil)
+}
+
+// RemoveVolume deletes a named volume.
+func (b *ContainerBackend) RemoveVolume(name string) error {
+	err := b.client.RemoveVolumeWithOptions(docker.RemoveVolumeOptions{Name: name})
+	if err == docker.ErrNoSuchVolume {
+		// The volume was never mounted.
+		return nil
+	}
+	return err
+}
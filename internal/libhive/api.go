@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -25,6 +26,11 @@ import (
 // be moved from test images to client container to fine tune their setup.
 const hiveEnvvarPrefix = "HIVE_"
 
+// hiveVolumePrefix is the prefix of client parameters which request a named volume
+// mount. The parameter name contains the volume name, the value is the mount path,
+// optionally followed by ":ro" or ":rw".
+const hiveVolumePrefix = "HIVE_VOLUME_"
+
 // This is the default timeout for starting clients.
 const defaultStartTimeout = time.Duration(60 * time.Second)
 
@@ -36,6 +42,8 @@ func newSimulationAPI(b ContainerBackend, env SimEnv, tm *TestManager) http.Hand
 	router := mux.NewRouter()
 	router.HandleFunc("/clients", api.getClientTypes).Methods("GET")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}/exec", api.execInClient).Methods("POST")
+	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}/hook/{name}", api.runHook).Methods("POST")
+	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}/files", api.downloadFiles).Methods("GET")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}", api.getEnodeURL).Methods("GET")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node", api.startClient).Methods("POST")
 	router.HandleFunc("/testsuite/{suite}/test/{test}/node/{node}", api.stopClient).Methods("DELETE")
@@ -44,11 +52,17 @@ func newSimulationAPI(b ContainerBackend, env SimEnv, tm *TestManager) http.Hand
 	router.HandleFunc("/testsuite/{suite}/test/{test}", api.endTest).Methods("POST")
 	router.HandleFunc("/testsuite", api.startSuite).Methods("POST")
 	router.HandleFunc("/testsuite/{suite}", api.endSuite).Methods("DELETE")
+	router.HandleFunc("/testsuite/{suite}/file", api.uploadFile).Methods("POST")
+	router.HandleFunc("/testsuite/{suite}/service", api.startService).Methods("POST")
+	router.HandleFunc("/testsuite/{suite}/service/{container}", api.stopService).Methods("DELETE")
+	router.HandleFunc("/testsuite/{suite}/volume/{volume}", api.volumeCreate).Methods("POST")
+	router.HandleFunc("/testsuite/{suite}/volume/{volume}", api.volumeRemove).Methods("DELETE")
 	router.HandleFunc("/testsuite/{suite}/network/{network}", api.networkCreate).Methods("POST")
 	router.HandleFunc("/testsuite/{suite}/network/{network}", api.networkRemove).Methods("DELETE")
 	router.HandleFunc("/testsuite/{suite}/network/{network}/{node}", api.networkIPGet).Methods("GET")
 	router.HandleFunc("/testsuite/{suite}/network/{network}/{node}", api.networkConnect).Methods("POST")
 	router.HandleFunc("/testsuite/{suite}/network/{network}/{node}", api.networkDisconnect).Methods("DELETE")
+	router.Use(env.Metrics.apiMiddleware)
 	return router
 }
 
@@ -195,18 +209,7 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "could not parse node request", http.StatusBadRequest)
 		return
 	}
-	files := make(map[string]*multipart.FileHeader)
-	for key, fheaders := range r.MultipartForm.File {
-		if len(fheaders) > 0 {
-			files[key] = fheaders[0]
-		}
-	}
-	env := make(map[string]string)
-	for key, vals := range r.MultipartForm.Value {
-		if strings.HasPrefix(key, hiveEnvvarPrefix) {
-			env[key] = vals[0]
-		}
-	}
+	files, env := formFilesAndEnv(r.MultipartForm)
 	// Set default client loglevel to sim loglevel.
 	if env["HIVE_LOGLEVEL"] == "" {
 		env["HIVE_LOGLEVEL"] = strconv.Itoa(api.env.SimLogLevel)
@@ -218,6 +221,53 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Decode the readiness probe.
+	var readiness *ReadinessProbe
+	if probe := r.MultipartForm.Value["readiness"]; len(probe) > 0 {
+		readiness = new(ReadinessProbe)
+		if err := json.Unmarshal([]byte(probe[0]), readiness); err != nil {
+			log15.Error("API: could not parse readiness probe", "error", err)
+			http.Error(w, "invalid readiness probe: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := readiness.Validate(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(readiness.Exec) > 0 {
+			if readiness.Exec, err = hiveBinCommand(readiness.Exec); err != nil {
+				http.Error(w, "invalid readiness probe: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	// Resolve references to uploaded files.
+	cachedFiles, err := api.uploadedFiles(suiteID, r.MultipartForm.Value["uploads"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Resolve volume mounts.
+	volumes, err := api.volumeMounts(suiteID, env)
+	if err != nil {
+		log15.Error("API: invalid volume mount", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Enable packet capture if requested. The simulator can override the default for
+	// specific clients.
+	capture := api.env.ClientPcap
+	if v := env["HIVE_PCAP"]; v != "" {
+		if capture, err = strconv.ParseBool(v); err != nil {
+			log15.Error("API: could not parse HIVE_PCAP", "value", v)
+			http.Error(w, "invalid HIVE_PCAP", http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Set up the timeout.
 	timeout := api.env.ClientStartTimeout
 	if timeout == 0 {
@@ -227,7 +277,7 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Create the client container.
-	options := ContainerOptions{Env: env, Files: files}
+	options := ContainerOptions{Env: env, Files: files, CachedFiles: cachedFiles, Volumes: volumes, Capture: capture, Readiness: readiness}
 	containerID, err := api.backend.CreateContainer(ctx, clientDef.Image, options)
 	if err != nil {
 		log15.Error("API: client container create failed", "client", clientDef.Name, "error", err)
@@ -252,8 +302,35 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 		options.CheckLive = uint16(v)
 	}
 
+	// Set the capture file.
+	var pcapPath string
+	if capture {
+		pcapPath, options.CaptureFile = api.clientFilePaths(clientDef.Name, fmt.Sprintf("client-%s.pcap", containerID))
+	}
+
+	// Configure the metrics scraper if requested.
+	var metricsPort uint64
+	if portStr := env["HIVE_METRICS_PORT"]; portStr != "" {
+		metricsPort, err = strconv.ParseUint(portStr, 10, 16)
+		if err != nil || metricsPort == 0 {
+			log15.Error("API: could not parse metrics port", "value", portStr)
+			http.Error(w, "invalid HIVE_METRICS_PORT", http.StatusBadRequest)
+			return
+		}
+	}
+	metricsPath := env["HIVE_METRICS_PATH"]
+	if metricsPath == "" {
+		metricsPath = "/metrics"
+	}
+
 	// Start it!
+	startTime := time.Now()
 	info, err := api.backend.StartContainer(ctx, containerID, options)
+	if err == nil {
+		api.env.Metrics.clientStarted(clientDef.Name, time.Since(startTime))
+	} else if ctx.Err() == context.DeadlineExceeded {
+		api.env.Metrics.clientStartTimeout(clientDef.Name)
+	}
 	if info != nil {
 		clientInfo := &ClientInfo{
 			ID:             info.ID,
@@ -262,6 +339,14 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 			InstantiatedAt: time.Now(),
 			LogFile:        logPath,
 			wait:           info.Wait,
+			usage:          info.Usage,
+		}
+		if info.CaptureFile != "" {
+			clientInfo.PcapFile = pcapPath
+		}
+		var metricsFile string
+		if err == nil && metricsPort != 0 {
+			clientInfo.MetricsFile, metricsFile = api.clientFilePaths(clientDef.Name, fmt.Sprintf("client-%s-metrics.jsonl", containerID))
 		}
 		api.tm.testSuiteMutex.Lock()
 
@@ -277,6 +362,17 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 
 		// register the node
 		api.tm.RegisterNode(testID, info.ID, clientInfo)
+
+		// Scrape metrics until the container stops.
+		if metricsFile != "" {
+			url := fmt.Sprintf("http://%s:%d%s", info.IP, metricsPort, metricsPath)
+			stop := make(chan struct{})
+			go scrapeClientMetrics(url, metricsFile, clientMetricsInterval, stop)
+			go func() {
+				info.Wait()
+				close(stop)
+			}()
+		}
 	}
 	if err != nil {
 		log15.Error("API: could not start client", "client", clientDef.Name, "container", containerID[:8], "error", err)
@@ -287,13 +383,124 @@ func (api *simAPI) startClient(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "%s@%s@%s", info.ID, info.IP, info.MAC)
 }
 
+// formFilesAndEnv returns the files and HIVE_ parameters of a container start request.
+func formFilesAndEnv(form *multipart.Form) (map[string]*multipart.FileHeader, map[string]string) {
+	files := make(map[string]*multipart.FileHeader)
+	for key, fheaders := range form.File {
+		if len(fheaders) > 0 {
+			files[key] = fheaders[0]
+		}
+	}
+	env := make(map[string]string)
+	for key, vals := range form.Value {
+		if strings.HasPrefix(key, hiveEnvvarPrefix) {
+			env[key] = vals[0]
+		}
+	}
+	return files, env
+}
+
+// startService starts an auxiliary service container of the simulator. Unlike
+// clients, services belong to the test suite and are stopped when the suite ends.
+func (api *simAPI) startService(w http.ResponseWriter, r *http.Request) {
+	suiteID, err := api.requestSuite(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Service launch parameters are given as multipart/form-data.
+	if err := r.ParseMultipartForm((1 << 10) * 4); err != nil {
+		log15.Error("API: could not parse service request", "error", err)
+		http.Error(w, "could not parse service request", http.StatusBadRequest)
+		return
+	}
+	name := r.FormValue("SERVICE")
+	image, ok := api.env.Services[name]
+	if !ok {
+		log15.Error("API: unknown service in start request", "service", name)
+		http.Error(w, fmt.Sprintf("unknown service %q", name), http.StatusBadRequest)
+		return
+	}
+	files, env := formFilesAndEnv(r.MultipartForm)
+	volumes, err := api.volumeMounts(suiteID, env)
+	if err != nil {
+		log15.Error("API: invalid volume mount", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	options := ContainerOptions{Env: env, Files: files, Volumes: volumes}
+
+	// Services are not checked for liveness unless requested.
+	if portStr := env["HIVE_CHECK_LIVE_PORT"]; portStr != "" {
+		v, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil {
+			log15.Error("API: could not parse check-live port", "error", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		options.CheckLive = uint16(v)
+	}
+
+	timeout := api.env.ClientStartTimeout
+	if timeout == 0 {
+		timeout = defaultStartTimeout
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	containerID, err := api.backend.CreateContainer(ctx, image, options)
+	if err != nil {
+		log15.Error("API: service container create failed", "service", name, "error", err)
+		http.Error(w, "service container create failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, options.LogFile = api.clientFilePaths(name, fmt.Sprintf("service-%s.log", containerID))
+	info, err := api.backend.StartContainer(ctx, containerID, options)
+	if info != nil {
+		api.tm.RegisterService(suiteID, info)
+	}
+	if err != nil {
+		log15.Error("API: could not start service", "service", name, "container", containerID[:8], "error", err)
+		http.Error(w, "service did not start: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log15.Info("API: service "+name+" started", "suite", suiteID, "container", containerID[:8])
+	fmt.Fprintf(w, "%s@%s@%s", info.ID, info.IP, info.MAC)
+}
+
+// stopService stops a service container.
+func (api *simAPI) stopService(w http.ResponseWriter, r *http.Request) {
+	suiteID, err := api.requestSuite(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	container := mux.Vars(r)["container"]
+	switch err := api.tm.StopService(suiteID, container); {
+	case err == ErrNoSuchService:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		log15.Error("API: can't stop service", "container", container, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		log15.Info("API: service stopped", "suite", suiteID, "container", container)
+		fmt.Fprint(w, "success")
+	}
+}
+
 // clientLogFilePaths determines the log file path of a client container.
 // Note that jsonPath gets written to the result JSON and always uses '/' as the separator.
 // The filePath is passed to the docker backend and uses the platform separator.
 func (api *simAPI) clientLogFilePaths(clientName, containerID string) (jsonPath string, file string) {
 	// TODO: might be nice to put timestamp into the filename as well.
+	return api.clientFilePaths(clientName, fmt.Sprintf("client-%s.log", containerID))
+}
+
+// clientFilePaths determines the path of a file in the log directory of a client.
+func (api *simAPI) clientFilePaths(clientName, fileName string) (jsonPath string, file string) {
 	safeDir := strings.Replace(clientName, string(filepath.Separator), "_", -1)
-	jsonPath = path.Join(safeDir, fmt.Sprintf("client-%s.log", containerID))
+	jsonPath = path.Join(safeDir, fileName)
 	file = filepath.Join(api.env.LogDir, filepath.FromSlash(jsonPath))
 	return jsonPath, file
 }
@@ -349,7 +556,7 @@ func (api *simAPI) getEnodeURL(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
-	output, err := api.backend.RunEnodeSh(r.Context(), nodeInfo.ID)
+	output, err := api.enodeOutput(r.Context(), nodeInfo)
 	if err != nil {
 		log15.Error("API: error running enode.sh", "node", node, "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -381,6 +588,61 @@ func (api *simAPI) getEnodeURL(w http.ResponseWriter, r *http.Request) {
 	io.WriteString(w, fixedIP.URLv4())
 }
 
+// enodeOutput runs the 'enode' hook of the client. Clients which don't declare the
+// hook use the /enode.sh script.
+func (api *simAPI) enodeOutput(ctx context.Context, nodeInfo *ClientInfo) (string, error) {
+	if def, ok := api.env.Definitions[nodeInfo.Name]; ok {
+		if cmd, ok := def.Meta.HookCommand("enode"); ok {
+			info, err := api.backend.RunProgram(ctx, nodeInfo.ID, cmd)
+			if err != nil {
+				return "", err
+			}
+			if info.ExitCode != 0 {
+				return "", fmt.Errorf("enode hook exited with code %d: %s", info.ExitCode, info.Stderr)
+			}
+			return info.Stdout, nil
+		}
+	}
+	return api.backend.RunEnodeSh(ctx, nodeInfo.ID)
+}
+
+// runHook runs a hook declared by the client.
+func (api *simAPI) runHook(w http.ResponseWriter, r *http.Request) {
+	suiteID, testID, err := api.requestSuiteAndTest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	node := mux.Vars(r)["node"]
+	nodeInfo, err := api.tm.GetNodeInfo(suiteID, testID, node)
+	if err != nil {
+		log15.Error("API: can't find node", "node", node, "error", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	def, ok := api.env.Definitions[nodeInfo.Name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown client %q", nodeInfo.Name), http.StatusNotFound)
+		return
+	}
+	cmd, ok := def.Meta.HookCommand(name)
+	if !ok {
+		msg := fmt.Sprintf("client %s has no hook %q", nodeInfo.Name, name)
+		http.Error(w, msg, http.StatusNotFound)
+		return
+	}
+	info, err := api.backend.RunProgram(r.Context(), nodeInfo.ID, cmd)
+	if err != nil {
+		log15.Error("API: client hook error", "node", node, "hook", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(&info)
+}
+
 func (api *simAPI) execInClient(w http.ResponseWriter, r *http.Request) {
 	suiteID, testID, err := api.requestSuiteAndTest(r)
 	if err != nil {
@@ -411,6 +673,55 @@ func (api *simAPI) execInClient(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(&info)
 }
 
+// downloadFiles responds with a tar archive of a file or directory in a client container.
+func (api *simAPI) downloadFiles(w http.ResponseWriter, r *http.Request) {
+	suiteID, testID, err := api.requestSuiteAndTest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	node := mux.Vars(r)["node"]
+	nodeInfo, err := api.tm.GetNodeInfo(suiteID, testID, node)
+	if err != nil {
+		log15.Error("API: can't find node", "node", node, "error", err)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	filePath := r.URL.Query().Get("path")
+	if !path.IsAbs(filePath) {
+		http.Error(w, fmt.Sprintf("path %q is not absolute", filePath), http.StatusBadRequest)
+		return
+	}
+
+	// The archive is streamed to the response. Errors can only be
+	// reported if no data was written yet.
+	w.Header().Set("content-type", "application/x-tar")
+	out := &countingWriter{w: w}
+	err = api.backend.DownloadFromContainer(r.Context(), nodeInfo.ID, filePath, out)
+	switch {
+	case err == nil:
+	case out.n > 0:
+		log15.Error("API: file download interrupted", "node", node, "path", filePath, "error", err)
+	case err == ErrPathNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		log15.Error("API: file download failed", "node", node, "path", filePath, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
+}
+
 // parseExecRequest decodes and validates a client script exec request.
 func parseExecRequest(r io.Reader) ([]string, error) {
 	var request struct {
@@ -419,15 +730,158 @@ func parseExecRequest(r io.Reader) ([]string, error) {
 	if err := json.NewDecoder(r).Decode(&request); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %v", err)
 	}
-	if len(request.Command) == 0 {
+	return hiveBinCommand(request.Command)
+}
+
+// hiveBinCommand validates a command line that should run a script in /hive-bin.
+func hiveBinCommand(command []string) ([]string, error) {
+	if len(command) == 0 {
 		return nil, errors.New("empty command")
 	}
-	script := request.Command[0]
+	script := command[0]
 	if strings.Contains(script, "/") {
 		return nil, errors.New("script name must not contain directory separator")
 	}
-	request.Command[0] = "/hive-bin/" + script
-	return request.Command, nil
+	cmd := append([]string{"/hive-bin/" + script}, command[1:]...)
+	return cmd, nil
+}
+
+// uploadFile stores the request body in the file cache of the test suite. The response
+// is the hash of the file, which can be used to reference the file when starting clients.
+func (api *simAPI) uploadFile(w http.ResponseWriter, r *http.Request) {
+	suiteID, err := api.requestSuite(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hash, err := api.tm.StoreFile(suiteID, r.Body)
+	if err != nil {
+		log15.Error("API: file upload failed", "error", err)
+		http.Error(w, "file upload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log15.Info("API: file uploaded", "suite", suiteID, "hash", hash)
+	fmt.Fprint(w, hash)
+}
+
+// uploadedFiles resolves the 'uploads' form field of a client start request. The field
+// contains a JSON object mapping destination paths to hashes of uploaded files.
+func (api *simAPI) uploadedFiles(suiteID TestSuiteID, field []string) (map[string]string, error) {
+	if len(field) == 0 {
+		return nil, nil
+	}
+	var uploads map[string]string
+	if err := json.Unmarshal([]byte(field[0]), &uploads); err != nil {
+		return nil, fmt.Errorf("invalid uploads: %v", err)
+	}
+	files := make(map[string]string, len(uploads))
+	for dst, hash := range uploads {
+		file, err := api.tm.StoredFile(suiteID, hash)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v %s", dst, err, hash)
+		}
+		files[dst] = file
+	}
+	return files, nil
+}
+
+// volumeNameRE matches valid volume names.
+var volumeNameRE = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// volumeMounts returns the volume mounts requested by HIVE_VOLUME_<name> parameters.
+func (api *simAPI) volumeMounts(suiteID TestSuiteID, env map[string]string) ([]VolumeMount, error) {
+	var mounts []VolumeMount
+	for key, value := range env {
+		if !strings.HasPrefix(key, hiveVolumePrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, hiveVolumePrefix)
+		if !volumeNameRE.MatchString(name) {
+			return nil, fmt.Errorf("invalid volume name %q", name)
+		}
+		mountPath, readOnly := value, false
+		switch {
+		case strings.HasSuffix(value, ":ro"):
+			mountPath, readOnly = strings.TrimSuffix(value, ":ro"), true
+		case strings.HasSuffix(value, ":rw"):
+			mountPath = strings.TrimSuffix(value, ":rw")
+		}
+		if !path.IsAbs(mountPath) {
+			return nil, fmt.Errorf("volume %s: mount path %q is not absolute", name, mountPath)
+		}
+		volume, err := api.tm.VolumeName(suiteID, name)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, VolumeMount{Volume: volume, Path: path.Clean(mountPath), ReadOnly: readOnly})
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Path < mounts[j].Path })
+	return mounts, nil
+}
+
+// volumeCreate creates a volume. Files given as multipart form data are
+// copied into the volume.
+func (api *simAPI) volumeCreate(w http.ResponseWriter, r *http.Request) {
+	suiteID, err := api.requestSuite(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	name := mux.Vars(r)["volume"]
+	if !volumeNameRE.MatchString(name) {
+		http.Error(w, fmt.Sprintf("invalid volume name %q", name), http.StatusBadRequest)
+		return
+	}
+
+	files := make(map[string]*multipart.FileHeader)
+	if strings.HasPrefix(r.Header.Get("content-type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm((1 << 10) * 4); err != nil {
+			log15.Error("API: could not parse volume request", "error", err)
+			http.Error(w, "could not parse volume request", http.StatusBadRequest)
+			return
+		}
+		for key, fheaders := range r.MultipartForm.File {
+			if len(fheaders) > 0 {
+				files[key] = fheaders[0]
+			}
+		}
+	}
+
+	err = api.tm.CreateVolume(r.Context(), suiteID, name, files)
+	switch {
+	case err == ErrVolumeExists || err == ErrNoSuchTestSuite:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		log15.Error("API: failed to create volume", "volume", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log15.Info("API: volume created", "name", name, "files", len(files))
+	fmt.Fprint(w, "success")
+}
+
+// volumeRemove removes a volume.
+func (api *simAPI) volumeRemove(w http.ResponseWriter, r *http.Request) {
+	suiteID, err := api.requestSuite(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := mux.Vars(r)["volume"]
+	err = api.tm.RemoveVolume(suiteID, name)
+	switch {
+	case err == ErrVolumeNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		log15.Error("API: failed to remove volume", "volume", name, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log15.Info("API: volume removed", "volume", name)
+	fmt.Fprint(w, "success")
 }
 
 // networkCreate creates a docker network.
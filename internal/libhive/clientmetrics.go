@@ -0,0 +1,146 @@
+package libhive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"gopkg.in/inconshreveable/log15.v2"
+)
+
+// This is the interval at which client metrics are scraped.
+const clientMetricsInterval = 5 * time.Second
+
+// metricsSample is a line of the client metrics file.
+type metricsSample struct {
+	Time    time.Time          `json:"time"`
+	Samples map[string]float64 `json:"samples"`
+}
+
+// scrapeClientMetrics fetches the Prometheus metrics of a client at url and appends them
+// to the given file, once per interval, until the stop channel is closed. The file is
+// created when the first scrape succeeds.
+func scrapeClientMetrics(url, file string, interval time.Duration, stop <-chan struct{}) {
+	var (
+		logger = log15.Root().New("url", url)
+		ticker = time.NewTicker(interval)
+		enc    *json.Encoder
+		failed bool
+	)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		samples, err := fetchMetrics(ctx, url)
+		cancel()
+		if err != nil {
+			// Scrapes can fail while the client starts up or shuts down,
+			// so only the first failure is logged.
+			if !failed {
+				logger.Debug("client metrics scrape failed", "err", err)
+				failed = true
+			}
+			continue
+		}
+		if enc == nil {
+			f, err := createMetricsFile(file)
+			if err != nil {
+				logger.Error("can't create client metrics file", "err", err)
+				return
+			}
+			defer f.Close()
+			enc = json.NewEncoder(f)
+		}
+		if err := enc.Encode(&metricsSample{Time: time.Now(), Samples: samples}); err != nil {
+			logger.Error("can't write client metrics", "err", err)
+			return
+		}
+	}
+}
+
+func createMetricsFile(file string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+}
+
+// fetchMetrics gets the current values of all series from a Prometheus endpoint.
+func fetchMetrics(ctx context.Context, url string) (map[string]float64, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return metricSamples(families), nil
+}
+
+// metricSamples flattens metric families into a map of series name to value.
+// For histograms and summaries, only the sum and count are kept.
+func metricSamples(families map[string]*dto.MetricFamily) map[string]float64 {
+	samples := make(map[string]float64)
+	add := func(name string, labels []*dto.LabelPair, v float64) {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return // not representable in JSON
+		}
+		samples[seriesName(name, labels)] = v
+	}
+	for name, mf := range families {
+		for _, m := range mf.Metric {
+			switch {
+			case m.Gauge != nil:
+				add(name, m.Label, m.Gauge.GetValue())
+			case m.Counter != nil:
+				add(name, m.Label, m.Counter.GetValue())
+			case m.Untyped != nil:
+				add(name, m.Label, m.Untyped.GetValue())
+			case m.Histogram != nil:
+				add(name+"_sum", m.Label, m.Histogram.GetSampleSum())
+				add(name+"_count", m.Label, float64(m.Histogram.GetSampleCount()))
+			case m.Summary != nil:
+				add(name+"_sum", m.Label, m.Summary.GetSampleSum())
+				add(name+"_count", m.Label, float64(m.Summary.GetSampleCount()))
+			}
+		}
+	}
+	return samples
+}
+
+// seriesName formats a series in Prometheus notation, e.g. name{label="value"}.
+func seriesName(name string, labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return name
+	}
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = fmt.Sprintf("%s=%q", l.GetName(), l.GetValue())
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
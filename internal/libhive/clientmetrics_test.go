@@ -0,0 +1,111 @@
+package libhive
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testMetricsOutput = `# HELP chain_head_block Current head block.
+# TYPE chain_head_block gauge
+chain_head_block 1234
+# TYPE p2p_peers gauge
+p2p_peers{protocol="eth",direction="in"} 3
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.1
+rpc_duration_seconds_sum 12.5
+rpc_duration_seconds_count 50
+# TYPE broken gauge
+broken NaN
+`
+
+func TestScrapeClientMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, testMetricsOutput)
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "hive-metrics-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "client", "metrics.jsonl")
+
+	// Scrape until the file has two samples.
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		scrapeClientMetrics(srv.URL, file, 10*time.Millisecond, stop)
+		close(done)
+	}()
+	var lines []metricsSample
+	for deadline := time.Now().Add(5 * time.Second); len(lines) < 2; {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for samples")
+		}
+		time.Sleep(20 * time.Millisecond)
+		lines = readMetricsFile(t, file)
+	}
+	close(stop)
+	<-done
+
+	want := map[string]float64{
+		"chain_head_block":                         1234,
+		`p2p_peers{direction="in",protocol="eth"}`: 3,
+		"rpc_duration_seconds_sum":                 12.5,
+		"rpc_duration_seconds_count":               50,
+	}
+	if !reflect.DeepEqual(lines[0].Samples, want) {
+		t.Fatalf("wrong samples %v\nwant %v", lines[0].Samples, want)
+	}
+}
+
+func TestScrapeClientMetricsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "hive-metrics-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "metrics.jsonl")
+
+	stop := make(chan struct{})
+	time.AfterFunc(100*time.Millisecond, func() { close(stop) })
+	scrapeClientMetrics(srv.URL, file, 10*time.Millisecond, stop)
+	if _, err := os.Stat(file); !os.IsNotExist(err) {
+		t.Fatal("metrics file was created without successful scrape")
+	}
+}
+
+func readMetricsFile(t *testing.T, file string) []metricsSample {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var samples []metricsSample
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var s metricsSample
+		if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+			// The last line may be incomplete while the scraper writes.
+			break
+		}
+		samples = append(samples, s)
+	}
+	return samples
+}
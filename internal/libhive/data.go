@@ -52,9 +52,41 @@ type ClientInfo struct {
 	IP             string    `json:"ip"`
 	Name           string    `json:"name"`
 	InstantiatedAt time.Time `json:"instantiatedAt"`
-	LogFile        string    `json:"logFile"` //Absolute path to the logfile.
+	LogFile        string    `json:"logFile"`               //Absolute path to the logfile.
+	MetricsFile    string    `json:"metricsFile,omitempty"` // Scraped client metrics, if requested.
+	PcapFile       string    `json:"pcapFile,omitempty"`    // Captured network traffic, if requested.
 
-	wait func()
+	// Resource usage of the client, available after it has stopped.
+	Resources *ResourceUsage `json:"resources,omitempty"`
+
+	wait  func()
+	usage func() *ResourceUsage
+}
+
+// collectUsage stores the resource usage of a stopped client.
+func (info *ClientInfo) collectUsage() {
+	if info.usage != nil {
+		info.Resources = info.usage()
+		info.usage = nil
+	}
+}
+
+// ResourceUsage summarizes the resources used by a client container while it was running.
+// Network and block I/O values are rates in bytes per second.
+type ResourceUsage struct {
+	Samples    int       `json:"samples"`
+	CPUPercent UsageStat `json:"cpuPercent"` // 100% is one fully used core
+	MemoryRSS  UsageStat `json:"memoryRSS"`  // resident memory in bytes
+	NetRx      UsageStat `json:"netRx"`
+	NetTx      UsageStat `json:"netTx"`
+	BlockRead  UsageStat `json:"blockRead"`
+	BlockWrite UsageStat `json:"blockWrite"`
+}
+
+// UsageStat is a sampled resource usage value.
+type UsageStat struct {
+	Peak float64 `json:"peak"`
+	Avg  float64 `json:"avg"`
 }
 
 // ExecInfo is the result of running a script in a client container.
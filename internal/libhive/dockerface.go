@@ -2,9 +2,14 @@ package libhive
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"net"
+	"regexp"
+	"strings"
 )
 
 // ContainerBackend captures the docker interactions of the simulation API.
@@ -20,6 +25,10 @@ type ContainerBackend interface {
 	// RunProgram runs a command in the given container and returns its outputs and exit code.
 	RunProgram(ctx context.Context, containerID string, cmdline []string) (*ExecInfo, error)
 
+	// DownloadFromContainer writes a tar archive of the given file or directory
+	// in the container to w.
+	DownloadFromContainer(ctx context.Context, containerID, path string, w io.Writer) error
+
 	// These methods configure docker networks.
 	NetworkNameToID(name string) (string, error)
 	CreateNetwork(name string) (string, error)
@@ -27,20 +36,86 @@ type ContainerBackend interface {
 	ContainerIP(containerID, networkID string) (net.IP, error)
 	ConnectContainer(containerID, networkID string) error
 	DisconnectContainer(containerID, networkID string) error
+
+	// These methods manage named volumes. CreateVolume copies the given files into
+	// the new volume. Volumes which don't exist are also created when they are
+	// first mounted into a container.
+	CreateVolume(ctx context.Context, name string, files map[string]*multipart.FileHeader) error
+	RemoveVolume(name string) error
 }
 
 // This error is returned by NetworkNameToID if a docker network is not present.
 var ErrNetworkNotFound = fmt.Errorf("network not found")
 
+// This error is returned by DownloadFromContainer if the path does not exist.
+var ErrPathNotFound = fmt.Errorf("path not found in container")
+
 // ContainerOptions contains the launch parameters for docker containers.
 type ContainerOptions struct {
 	// These options apply when creating the container.
 	Env   map[string]string
 	Files map[string]*multipart.FileHeader
 
+	// These files are copied from hive's file cache.
+	// Map: destination path -> file path on the host.
+	CachedFiles map[string]string
+
+	// Volumes are mounted into the container when creating it.
+	Volumes []VolumeMount
+
+	// Capture enables packet capture. Capture starts before the container is
+	// created, so no traffic of the container is missed.
+	Capture bool
+
 	// These options apply when starting the container.
-	CheckLive uint16 // requests check for the given TCP port
-	LogFile   string // if set, container output is written to this file
+	CheckLive   uint16          // requests check for the given TCP port
+	Readiness   *ReadinessProbe // if set, these checks must pass after CheckLive
+	LogFile     string          // if set, container output is written to this file
+	CaptureFile string          // if Capture is set, captured traffic is written to this pcap file
+}
+
+// VolumeMount is a named volume mounted into a container.
+type VolumeMount struct {
+	Volume   string // backend volume name
+	Path     string // absolute mount path in the container
+	ReadOnly bool
+}
+
+// ReadinessProbe configures checks which determine whether a started container is
+// ready for use. All configured checks must pass.
+type ReadinessProbe struct {
+	RPC       *RPCProbe `json:"rpc,omitempty"`       // JSON-RPC call with expected result
+	Exec      []string  `json:"exec,omitempty"`      // command which must exit with code zero
+	LogRegexp string    `json:"logRegexp,omitempty"` // regular expression matching an output line
+}
+
+// RPCProbe is a readiness check which performs a JSON-RPC call over HTTP.
+type RPCProbe struct {
+	Port   uint16        `json:"port,omitempty"` // defaults to 8545
+	Method string        `json:"method"`
+	Params []interface{} `json:"params,omitempty"`
+
+	// The expected result. If Result is set, the call must return this exact
+	// JSON value. If AtLeast is set, the result must be a hex quantity >= AtLeast.
+	// Otherwise, any non-error response is accepted.
+	Result  json.RawMessage `json:"result,omitempty"`
+	AtLeast *uint64         `json:"atLeast,omitempty"`
+}
+
+// Validate checks the probe for errors.
+func (p *ReadinessProbe) Validate() error {
+	if p.RPC != nil && p.RPC.Method == "" {
+		return errors.New("readiness: missing RPC method")
+	}
+	if p.RPC != nil && p.RPC.Result != nil && p.RPC.AtLeast != nil {
+		return errors.New("readiness: RPC result and atLeast are exclusive")
+	}
+	if p.LogRegexp != "" {
+		if _, err := regexp.Compile(p.LogRegexp); err != nil {
+			return fmt.Errorf("readiness: invalid log regexp: %v", err)
+		}
+	}
+	return nil
 }
 
 // ContainerInfo is returned by StartContainer.
@@ -50,22 +125,61 @@ type ContainerInfo struct {
 	MAC     string // MAC address. TODO: remove
 	LogFile string
 
+	// CaptureFile is the pcap file of the container's network traffic.
+	// This is empty if no capture was requested or the capture failed to start.
+	CaptureFile string
+
 	// The wait function returns when the container is stopped.
 	// This must be called for all containers that were started
 	// to avoid resource leaks.
 	Wait func()
+
+	// The usage function returns the resources used by the container.
+	// It may be nil if the backend does not collect usage information.
+	// This must only be called after Wait has returned.
+	Usage func() *ResourceUsage
 }
 
 // ClientMetadata is metadata to describe the client in more detail, configured with a YAML file in the client dir.
 type ClientMetadata struct {
 	Roles []string `yaml:"roles" json:"roles"`
+
+	// Hooks maps hook names to commands in the client container. Simulators
+	// run hooks by name through the API.
+	Hooks map[string]string `yaml:"hooks" json:"hooks,omitempty"`
+}
+
+// HookCommand returns the command line of the named hook.
+func (m *ClientMetadata) HookCommand(name string) ([]string, bool) {
+	cmd, ok := m.Hooks[name]
+	if !ok {
+		return nil, false
+	}
+	fields := strings.Fields(cmd)
+	return fields, len(fields) > 0
+}
+
+// SimulatorMetadata is metadata of a simulator, configured with a YAML file in the
+// simulator dir.
+type SimulatorMetadata struct {
+	// Services are auxiliary containers which the simulator can start through the
+	// API. Each service is built from the subdirectory of the same name.
+	Services []string `yaml:"services" json:"services"`
+
+	// BuildContext is the docker build context of the simulator image, relative to the
+	// simulator directory. It must contain the simulator directory. This is useful for
+	// simulators which depend on packages elsewhere in the hive repository. The default
+	// context is the simulator directory.
+	BuildContext string `yaml:"build_context" json:"build_context,omitempty"`
 }
 
 // Builder can build docker images of clients and simulators.
 type Builder interface {
 	ReadClientMetadata(name string) (*ClientMetadata, error)
+	ReadSimulatorMetadata(name string) (*SimulatorMetadata, error)
 	BuildClientImage(ctx context.Context, name string) (string, error)
 	BuildSimulatorImage(ctx context.Context, name string) (string, error)
+	BuildServiceImage(ctx context.Context, sim, service string) (string, error)
 
 	// ReadFile returns the content of a file in the given image.
 	ReadFile(image, path string) ([]byte, error)
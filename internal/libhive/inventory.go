@@ -52,6 +52,12 @@ func (inv Inventory) SimulatorDirectory(name string) string {
 	return filepath.Join(inv.BaseDir, "simulators", filepath.FromSlash(name))
 }
 
+// ServiceDirectory returns the directory containing the Dockerfile of an auxiliary
+// service of the given simulator.
+func (inv Inventory) ServiceDirectory(sim, service string) string {
+	return filepath.Join(inv.SimulatorDirectory(sim), filepath.FromSlash(service))
+}
+
 // AddClient ensures the given client name is known to the inventory.
 // This method exists for unit testing purposes only.
 func (inv *Inventory) AddClient(name string) {
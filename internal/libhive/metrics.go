@@ -0,0 +1,168 @@
+package libhive
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics collects operational metrics of hive. All methods can be called on a
+// nil *Metrics, in which case they do nothing.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	runningSuites prometheus.Gauge
+	runningTests  prometheus.Gauge
+	clientStart   *prometheus.HistogramVec
+	startTimeouts *prometheus.CounterVec
+	builds        *prometheus.HistogramVec
+	apiRequests   *prometheus.CounterVec
+	testResults   *prometheus.CounterVec
+}
+
+// NewMetrics creates the hive metrics.
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		runningSuites: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "hive_running_suites",
+			Help: "Number of test suites currently running.",
+		}),
+		runningTests: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "hive_running_tests",
+			Help: "Number of test cases currently running.",
+		}),
+		clientStart: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hive_client_start_seconds",
+			Help:    "Time until a client container is ready for use.",
+			Buckets: []float64{0.5, 1, 2, 5, 10, 20, 30, 60, 120, 300},
+		}, []string{"client"}),
+		startTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hive_client_start_timeouts_total",
+			Help: "Number of client containers which did not become ready within the timeout.",
+		}, []string{"client"}),
+		builds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "hive_image_build_seconds",
+			Help:    "Duration of docker image builds.",
+			Buckets: []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200},
+		}, []string{"kind", "name"}),
+		apiRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hive_api_requests_total",
+			Help: "Number of simulation API requests.",
+		}, []string{"route", "method", "code"}),
+		testResults: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hive_test_results_total",
+			Help: "Number of finished test cases.",
+		}, []string{"simulator", "client", "result"}),
+	}
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.runningSuites,
+		m.runningTests,
+		m.clientStart,
+		m.startTimeouts,
+		m.builds,
+		m.apiRequests,
+		m.testResults,
+	)
+	return m
+}
+
+// Handler returns an HTTP handler serving the metrics in Prometheus format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveBuild records the duration of an image build. Kind is "client" or "simulator".
+func (m *Metrics) ObserveBuild(kind, name string, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.builds.WithLabelValues(kind, name).Observe(d.Seconds())
+}
+
+func (m *Metrics) suiteStarted() {
+	if m != nil {
+		m.runningSuites.Inc()
+	}
+}
+
+func (m *Metrics) suiteEnded() {
+	if m != nil {
+		m.runningSuites.Dec()
+	}
+}
+
+func (m *Metrics) testStarted() {
+	if m != nil {
+		m.runningTests.Inc()
+	}
+}
+
+// testEnded records the result of a test case for all clients involved in it.
+func (m *Metrics) testEnded(simulator string, test *TestCase) {
+	if m == nil {
+		return
+	}
+	m.runningTests.Dec()
+	result := "fail"
+	if test.SummaryResult.Pass {
+		result = "pass"
+	}
+	clients := make(map[string]bool)
+	for _, info := range test.ClientInfo {
+		clients[info.Name] = true
+	}
+	if len(clients) == 0 {
+		clients[""] = true
+	}
+	for client := range clients {
+		m.testResults.WithLabelValues(simulator, client, result).Inc()
+	}
+}
+
+func (m *Metrics) clientStarted(client string, d time.Duration) {
+	if m != nil {
+		m.clientStart.WithLabelValues(client).Observe(d.Seconds())
+	}
+}
+
+func (m *Metrics) clientStartTimeout(client string) {
+	if m != nil {
+		m.startTimeouts.WithLabelValues(client).Inc()
+	}
+}
+
+// apiMiddleware counts requests to the simulation API by route.
+func (m *Metrics) apiMiddleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+		route := "unknown"
+		if cr := mux.CurrentRoute(r); cr != nil {
+			if tmpl, err := cr.GetPathTemplate(); err == nil {
+				route = tmpl
+			}
+		}
+		m.apiRequests.WithLabelValues(route, r.Method, strconv.Itoa(rw.status)).Inc()
+	})
+}
+
+// statusRecorder remembers the status code of an HTTP response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *statusRecorder) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
@@ -1,11 +1,16 @@
 package libhive
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -26,6 +31,10 @@ var (
 	ErrNoSummaryResult          = errors.New("test case must be ended with a summary result")
 	ErrDBUpdateFailed           = errors.New("could not update results set")
 	ErrTestSuiteLimited         = errors.New("testsuite test count is limited")
+	ErrVolumeNotFound           = errors.New("volume not found")
+	ErrVolumeExists             = errors.New("volume already exists")
+	ErrNoSuchFile               = errors.New("no such uploaded file")
+	ErrNoSuchService            = errors.New("no such service")
 )
 
 // ClientDefinition is served by the /clients API endpoint to list the available clients
@@ -41,16 +50,27 @@ type SimEnv struct {
 	LogDir string
 
 	// Parameters of simulation.
-	SimLogLevel    int
-	SimParallelism int
-	SimTestLimit   int
+	SimLogLevel       int
+	SimParallelism    int
+	SimTestLimit      int
+	SimCheckPostState bool
+	SimReuseClients   bool
 
 	// This configures the amount of time the simulation waits
 	// for the client to open port 8545 after launching the container.
 	ClientStartTimeout time.Duration
 
+	// This enables packet capture for all client containers.
+	ClientPcap bool
+
 	// client name -> client definition
 	Definitions map[string]*ClientDefinition
+
+	// service name -> image of auxiliary service declared by the simulator
+	Services map[string]string
+
+	// Operational metrics are recorded here if non-nil.
+	Metrics *Metrics
 }
 
 // TestManager collects test results during a simulation run.
@@ -59,6 +79,7 @@ type TestManager struct {
 	backend     ContainerBackend
 	testLimiter int
 
+	simName        string
 	simContainerID string
 	simLogFile     string
 
@@ -67,6 +88,21 @@ type TestManager struct {
 	networks     map[TestSuiteID]map[string]string
 	networkMutex sync.RWMutex
 
+	// all volumes used by a specific test suite, where key
+	// is volume name and value is the backend volume name
+	volumes     map[TestSuiteID]map[string]string
+	volumeMutex sync.Mutex
+
+	// directories of files uploaded by a specific test suite.
+	// Files in these directories are named by their SHA256 hash.
+	uploads     map[TestSuiteID]string
+	uploadMutex sync.Mutex
+
+	// all service containers started by a specific test suite,
+	// where key is the container ID
+	services     map[TestSuiteID]map[string]*ContainerInfo
+	serviceMutex sync.Mutex
+
 	testCaseMutex     sync.RWMutex
 	testSuiteMutex    sync.RWMutex
 	runningTestSuites map[TestSuiteID]*TestSuite
@@ -85,12 +121,16 @@ func NewTestManager(config SimEnv, b ContainerBackend, testLimiter int) *TestMan
 		runningTestCases:  make(map[TestID]*TestCase),
 		results:           make(map[TestSuiteID]*TestSuite),
 		networks:          make(map[TestSuiteID]map[string]string),
+		volumes:           make(map[TestSuiteID]map[string]string),
+		uploads:           make(map[TestSuiteID]string),
+		services:          make(map[TestSuiteID]map[string]*ContainerInfo),
 	}
 }
 
 // SetSimContainerInfo makes the manager aware of the simulation container.
 // This must be called after creating the simulation container, but before starting it.
-func (manager *TestManager) SetSimContainerInfo(id, logFile string) {
+func (manager *TestManager) SetSimContainerInfo(sim, id, logFile string) {
+	manager.simName = sim
 	manager.simContainerID = id
 	manager.simLogFile = logFile
 }
@@ -197,7 +237,7 @@ func (manager *TestManager) CreateNetwork(testSuite TestSuiteID, name string) er
 	return nil
 }
 
-// getUniqueName returns a unique network name to prevent network collisions
+// getUniqueName returns a unique network or volume name to prevent collisions
 func getUniqueName(testSuite TestSuiteID, name string) string {
 	return fmt.Sprintf("hive_%d_%d_%s", os.Getpid(), testSuite, name)
 }
@@ -235,6 +275,215 @@ func (manager *TestManager) PruneNetworks(testSuite TestSuiteID) []error {
 	return errs
 }
 
+// CreateVolume creates a volume containing the given files. The volume is removed
+// when the suite ends.
+func (manager *TestManager) CreateVolume(ctx context.Context, testSuite TestSuiteID, name string, files map[string]*multipart.FileHeader) error {
+	_, ok := manager.IsTestSuiteRunning(testSuite)
+	if !ok {
+		return ErrNoSuchTestSuite
+	}
+
+	manager.volumeMutex.Lock()
+	defer manager.volumeMutex.Unlock()
+
+	if _, exists := manager.volumes[testSuite][name]; exists {
+		return ErrVolumeExists
+	}
+	id := getUniqueName(testSuite, name)
+	if err := manager.backend.CreateVolume(ctx, id, files); err != nil {
+		return err
+	}
+	if _, exists := manager.volumes[testSuite]; !exists {
+		manager.volumes[testSuite] = make(map[string]string)
+	}
+	manager.volumes[testSuite][name] = id
+	return nil
+}
+
+// VolumeName returns the backend name of the given volume. If the volume was not
+// created yet, it is registered with the test suite and removed when the suite ends.
+func (manager *TestManager) VolumeName(testSuite TestSuiteID, name string) (string, error) {
+	_, ok := manager.IsTestSuiteRunning(testSuite)
+	if !ok {
+		return "", ErrNoSuchTestSuite
+	}
+
+	manager.volumeMutex.Lock()
+	defer manager.volumeMutex.Unlock()
+
+	if _, exists := manager.volumes[testSuite]; !exists {
+		manager.volumes[testSuite] = make(map[string]string)
+	}
+	id, exists := manager.volumes[testSuite][name]
+	if !exists {
+		id = getUniqueName(testSuite, name)
+		manager.volumes[testSuite][name] = id
+	}
+	return id, nil
+}
+
+// RemoveVolume removes a volume. It fails if the volume is still mounted.
+func (manager *TestManager) RemoveVolume(testSuite TestSuiteID, name string) error {
+	manager.volumeMutex.Lock()
+	defer manager.volumeMutex.Unlock()
+
+	id, exists := manager.volumes[testSuite][name]
+	if !exists {
+		return ErrVolumeNotFound
+	}
+	if err := manager.backend.RemoveVolume(id); err != nil {
+		return err
+	}
+	delete(manager.volumes[testSuite], name)
+	return nil
+}
+
+// PruneVolumes removes all volumes used by the given test suite.
+func (manager *TestManager) PruneVolumes(testSuite TestSuiteID) []error {
+	manager.volumeMutex.Lock()
+	defer manager.volumeMutex.Unlock()
+
+	var errs []error
+	for name, id := range manager.volumes[testSuite] {
+		log15.Info("removing docker volume", "name", name)
+		if err := manager.backend.RemoveVolume(id); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	delete(manager.volumes, testSuite)
+	return errs
+}
+
+// StoreFile adds the content of r to the file cache of the test suite. It returns the
+// SHA256 hash of the content, which identifies the file in later requests.
+func (manager *TestManager) StoreFile(testSuite TestSuiteID, r io.Reader) (string, error) {
+	_, ok := manager.IsTestSuiteRunning(testSuite)
+	if !ok {
+		return "", ErrNoSuchTestSuite
+	}
+	dir, err := manager.uploadDir(testSuite)
+	if err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file first, the name is known only after hashing.
+	tmp, err := ioutil.TempFile(dir, "upload-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, hash)); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// StoredFile returns the path of a file in the file cache of the test suite.
+func (manager *TestManager) StoredFile(testSuite TestSuiteID, hash string) (string, error) {
+	manager.uploadMutex.Lock()
+	dir, ok := manager.uploads[testSuite]
+	manager.uploadMutex.Unlock()
+
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != sha256.Size || !ok {
+		return "", ErrNoSuchFile
+	}
+	file := filepath.Join(dir, hash)
+	if _, err := os.Stat(file); err != nil {
+		return "", ErrNoSuchFile
+	}
+	return file, nil
+}
+
+// uploadDir returns the file cache directory of the test suite, creating it if needed.
+func (manager *TestManager) uploadDir(testSuite TestSuiteID) (string, error) {
+	manager.uploadMutex.Lock()
+	defer manager.uploadMutex.Unlock()
+
+	if dir, ok := manager.uploads[testSuite]; ok {
+		return dir, nil
+	}
+	dir, err := ioutil.TempDir("", fmt.Sprintf("hive-uploads-%d-", testSuite))
+	if err != nil {
+		return "", err
+	}
+	manager.uploads[testSuite] = dir
+	return dir, nil
+}
+
+// pruneUploads removes the file cache of the test suite.
+func (manager *TestManager) pruneUploads(testSuite TestSuiteID) error {
+	manager.uploadMutex.Lock()
+	defer manager.uploadMutex.Unlock()
+
+	dir, ok := manager.uploads[testSuite]
+	if !ok {
+		return nil
+	}
+	delete(manager.uploads, testSuite)
+	return os.RemoveAll(dir)
+}
+
+// RegisterService makes the manager aware of a service container started by the
+// test suite. The container is stopped when the suite ends.
+func (manager *TestManager) RegisterService(testSuite TestSuiteID, info *ContainerInfo) {
+	manager.serviceMutex.Lock()
+	defer manager.serviceMutex.Unlock()
+
+	if _, exists := manager.services[testSuite]; !exists {
+		manager.services[testSuite] = make(map[string]*ContainerInfo)
+	}
+	manager.services[testSuite][info.ID] = info
+}
+
+// StopService stops a service container.
+func (manager *TestManager) StopService(testSuite TestSuiteID, containerID string) error {
+	manager.serviceMutex.Lock()
+	info, ok := manager.services[testSuite][containerID]
+	delete(manager.services[testSuite], containerID)
+	manager.serviceMutex.Unlock()
+
+	if !ok {
+		return ErrNoSuchService
+	}
+	return manager.stopContainer(info)
+}
+
+// stopServices stops all service containers of the test suite.
+func (manager *TestManager) stopServices(testSuite TestSuiteID) []error {
+	manager.serviceMutex.Lock()
+	services := manager.services[testSuite]
+	delete(manager.services, testSuite)
+	manager.serviceMutex.Unlock()
+
+	var errs []error
+	for _, info := range services {
+		log15.Info("stopping service container", "container", info.ID)
+		if err := manager.stopContainer(info); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (manager *TestManager) stopContainer(info *ContainerInfo) error {
+	if err := manager.backend.DeleteContainer(info.ID); err != nil {
+		return err
+	}
+	if info.Wait != nil {
+		info.Wait()
+	}
+	return nil
+}
+
 // ContainerIP gets the IP address of the given container on the given network.
 func (manager *TestManager) ContainerIP(testSuite TestSuiteID, networkName, containerID string) (string, error) {
 	manager.networkMutex.RLock()
@@ -339,15 +588,32 @@ func (manager *TestManager) doEndSuite(testSuite TestSuiteID) error {
 			return err
 		}
 	}
+	// stop the test suite's service containers.
+	if errs := manager.stopServices(testSuite); len(errs) > 0 {
+		for _, err := range errs {
+			log15.Error("could not stop service", "err", err)
+		}
+	}
 	// remove the test suite's left-over docker networks.
 	if errs := manager.PruneNetworks(testSuite); len(errs) > 0 {
 		for _, err := range errs {
 			log15.Error("could not remove network", "err", err)
 		}
 	}
+	// remove the test suite's volumes.
+	if errs := manager.PruneVolumes(testSuite); len(errs) > 0 {
+		for _, err := range errs {
+			log15.Error("could not remove volume", "err", err)
+		}
+	}
+	// remove uploaded files.
+	if err := manager.pruneUploads(testSuite); err != nil {
+		log15.Error("could not remove uploaded files", "err", err)
+	}
 	// Move the suite to results.
 	delete(manager.runningTestSuites, testSuite)
 	manager.results[testSuite] = suite
+	manager.config.Metrics.suiteEnded()
 	return nil
 }
 
@@ -366,6 +632,7 @@ func (manager *TestManager) StartTestSuite(name string, description string) (Tes
 		SimulatorLog:   manager.simLogFile,
 	}
 	manager.testSuiteCounter++
+	manager.config.Metrics.suiteStarted()
 	return newSuiteID, nil
 }
 
@@ -396,6 +663,7 @@ func (manager *TestManager) StartTest(testSuiteID TestSuiteID, name string, desc
 	testSuite.TestCases[newCaseID] = newTestCase
 	// and to the general map of id:testcases
 	manager.runningTestCases[newCaseID] = newTestCase
+	manager.config.Metrics.testStarted()
 
 	return newCaseID, nil
 }
@@ -425,11 +693,13 @@ func (manager *TestManager) EndTest(testSuiteRun TestSuiteID, testID TestID, sum
 			manager.backend.DeleteContainer(v.ID)
 			v.wait()
 			v.wait = nil
+			v.collectUsage()
 		}
 	}
 
 	// Delete from running, if it's still there.
 	delete(manager.runningTestCases, testID)
+	manager.config.Metrics.testEnded(manager.simName, testCase)
 	return nil
 }
 
@@ -470,6 +740,7 @@ func (manager *TestManager) StopNode(testID TestID, nodeID string) error {
 		}
 		nodeInfo.wait()
 		nodeInfo.wait = nil
+		nodeInfo.collectUsage()
 	}
 	return nil
 }
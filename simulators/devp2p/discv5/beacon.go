@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ethereum/hive/hivesim"
+	blsu "github.com/protolambda/bls12-381-util"
+	"github.com/protolambda/zrnt/eth2/beacon/common"
+	"github.com/protolambda/zrnt/eth2/beacon/phase0"
+	"github.com/protolambda/zrnt/eth2/configs"
+	"github.com/protolambda/ztyp/codec"
+)
+
+// beaconValidators is the number of validators in the genesis state. The beacon node
+// only needs a valid genesis state to start discovery, so the validator set is small
+// and no validator is ever run.
+const beaconValidators = 64
+
+// beaconSpec returns the chain configuration of beacon nodes. It uses the mainnet
+// preset with a custom fork version, so beacon nodes will not connect to any public
+// network.
+func beaconSpec() *common.Spec {
+	spec := *configs.Mainnet
+	spec.Config.DEPOSIT_CONTRACT_ADDRESS.UnmarshalText([]byte("0x4242424242424242424242424242424242424242"))
+	spec.Config.GENESIS_FORK_VERSION = common.Version{0xff, 0, 0, 0}
+	spec.Config.ALTAIR_FORK_VERSION = common.Version{0xff, 0, 0, 1}
+	spec.Config.ALTAIR_FORK_EPOCH = 10
+	spec.Config.MIN_GENESIS_ACTIVE_VALIDATOR_COUNT = beaconValidators
+	return &spec
+}
+
+// beaconParams returns the client parameters of beacon nodes.
+func beaconParams(config *common.Config) hivesim.Params {
+	u64 := func(v uint64) string { return fmt.Sprintf("%d", v) }
+	params := hivesim.Params{
+		"HIVE_LOGLEVEL":         "5",
+		"HIVE_ETH2_BN_API_PORT": u64(beaconAPIPort),
+		"HIVE_CHECK_LIVE_PORT":  u64(beaconAPIPort),
+	}
+	chain := map[string]string{
+		"PRESET_BASE":                         config.PRESET_BASE,
+		"MIN_GENESIS_ACTIVE_VALIDATOR_COUNT":  u64(config.MIN_GENESIS_ACTIVE_VALIDATOR_COUNT),
+		"MIN_GENESIS_TIME":                    config.MIN_GENESIS_TIME.String(),
+		"GENESIS_FORK_VERSION":                config.GENESIS_FORK_VERSION.String(),
+		"GENESIS_DELAY":                       config.GENESIS_DELAY.String(),
+		"ALTAIR_FORK_VERSION":                 config.ALTAIR_FORK_VERSION.String(),
+		"ALTAIR_FORK_EPOCH":                   config.ALTAIR_FORK_EPOCH.String(),
+		"MERGE_FORK_VERSION":                  config.MERGE_FORK_VERSION.String(),
+		"MERGE_FORK_EPOCH":                    config.MERGE_FORK_EPOCH.String(),
+		"MIN_ANCHOR_POW_BLOCK_DIFFICULTY":     u64(config.MIN_ANCHOR_POW_BLOCK_DIFFICULTY),
+		"SECONDS_PER_SLOT":                    config.SECONDS_PER_SLOT.String(),
+		"SECONDS_PER_ETH1_BLOCK":              u64(config.SECONDS_PER_ETH1_BLOCK),
+		"MIN_VALIDATOR_WITHDRAWABILITY_DELAY": config.MIN_VALIDATOR_WITHDRAWABILITY_DELAY.String(),
+		"SHARD_COMMITTEE_PERIOD":              config.SHARD_COMMITTEE_PERIOD.String(),
+		"ETH1_FOLLOW_DISTANCE":                u64(config.ETH1_FOLLOW_DISTANCE),
+		"INACTIVITY_SCORE_BIAS":               u64(config.INACTIVITY_SCORE_BIAS),
+		"INACTIVITY_SCORE_RECOVERY_RATE":      u64(config.INACTIVITY_SCORE_RECOVERY_RATE),
+		"EJECTION_BALANCE":                    config.EJECTION_BALANCE.String(),
+		"MIN_PER_EPOCH_CHURN_LIMIT":           u64(config.MIN_PER_EPOCH_CHURN_LIMIT),
+		"CHURN_LIMIT_QUOTIENT":                u64(config.CHURN_LIMIT_QUOTIENT),
+		"DEPOSIT_CHAIN_ID":                    u64(config.DEPOSIT_CHAIN_ID),
+		"DEPOSIT_NETWORK_ID":                  u64(config.DEPOSIT_NETWORK_ID),
+		"DEPOSIT_CONTRACT_ADDRESS":            config.DEPOSIT_CONTRACT_ADDRESS.String(),
+	}
+	for k, v := range chain {
+		params["HIVE_ETH2_CONFIG_"+k] = v
+	}
+	return params
+}
+
+// writeBeaconGenesis creates the genesis state and writes it to a temporary file. The
+// state is deterministic: validator keys are derived from their index and the genesis
+// time is MIN_GENESIS_TIME.
+func writeBeaconGenesis(spec *common.Spec) (string, error) {
+	validators := make([]phase0.KickstartValidatorData, beaconValidators)
+	for i := range validators {
+		pub, err := validatorPubkey(uint64(i))
+		if err != nil {
+			return "", err
+		}
+		validators[i] = phase0.KickstartValidatorData{
+			Pubkey:                pub,
+			WithdrawalCredentials: common.Root(sha256.Sum256(pub[:])),
+			Balance:               spec.MAX_EFFECTIVE_BALANCE,
+		}
+		validators[i].WithdrawalCredentials[0] = common.BLS_WITHDRAWAL_PREFIX
+	}
+	state, _, err := phase0.KickStartState(spec, common.Root{0: 0x42}, spec.MIN_GENESIS_TIME, validators)
+	if err != nil {
+		return "", fmt.Errorf("can't create genesis state: %v", err)
+	}
+
+	f, err := ioutil.TempFile("", "genesis-*.ssz")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := state.Serialize(codec.NewEncodingWriter(f)); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("can't write genesis state: %v", err)
+	}
+	return f.Name(), nil
+}
+
+// validatorPubkey derives the public key of a genesis validator.
+func validatorPubkey(index uint64) (common.BLSPubkey, error) {
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], index)
+	secret := sha256.Sum256(append([]byte("hive discv5 validator"), seed[:]...))
+	var sk blsu.SecretKey
+	if err := sk.Deserialize(&secret); err != nil {
+		return common.BLSPubkey{}, err
+	}
+	pub, err := blsu.SkToPk(&sk)
+	if err != nil {
+		return common.BLSPubkey{}, err
+	}
+	return pub.Serialize(), nil
+}
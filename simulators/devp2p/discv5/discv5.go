@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/ethereum/hive/hivesim"
+	"github.com/shogo82148/go-tap"
+)
+
+// This is the port of the beacon node REST API.
+const beaconAPIPort = 4000
+
+func main() {
+	spec := beaconSpec()
+	genesis, err := writeBeaconGenesis(spec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "can't create beacon genesis:", err)
+		os.Exit(1)
+	}
+	defer os.Remove(genesis)
+
+	suite := hivesim.Suite{
+		Name:        "discv5",
+		Description: "This runs the Discovery v5 test suite from go-ethereum, followed by handshake and ENR update tests.",
+	}
+	suite.Add(hivesim.ClientTestSpec{
+		Role: "eth1",
+		Name: "discv5 (CLIENT)",
+		Description: `This test launches an eth1 client with discovery v5 enabled and runs the test tool.
+Results from the test tool are reported as individual sub-tests.`,
+		Parameters: hivesim.Params{
+			"HIVE_LOGLEVEL": "5",
+			"HIVE_DISCV5":   "1",
+		},
+		Run: func(t *hivesim.T, c *hivesim.Client) {
+			nodeURL, err := c.EnodeURL()
+			if err != nil {
+				t.Fatal("can't get client enode URL:", err)
+			}
+			runDiscoveryTest(t, c, nodeURL)
+		},
+	})
+	suite.Add(hivesim.ClientTestSpec{
+		Role: "beacon",
+		Name: "discv5 (CLIENT)",
+		Description: `This test launches a beacon node with a generated genesis state and runs the test
+tool against its ENR. Results from the test tool are reported as individual sub-tests.`,
+		Parameters: beaconParams(&spec.Config),
+		Files: map[string]string{
+			"/hive/input/genesis.ssz": genesis,
+		},
+		Run: func(t *hivesim.T, c *hivesim.Client) {
+			enr, err := beaconENR(c)
+			if err != nil {
+				t.Fatal("can't get beacon node ENR:", err)
+			}
+			runDiscoveryTest(t, c, enr)
+		},
+	})
+	hivesim.MustRunSuite(hivesim.New(), suite)
+}
+
+// beaconENR fetches the node record of a beacon node using the REST API.
+func beaconENR(c *hivesim.Client) (string, error) {
+	url := fmt.Sprintf("http://%v:%d/eth/v1/node/identity", c.IP, beaconAPIPort)
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected HTTP status %s", resp.Status)
+	}
+	var identity struct {
+		Data struct {
+			ENR string `json:"enr"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&identity); err != nil {
+		return "", err
+	}
+	if identity.Data.ENR == "" {
+		return "", fmt.Errorf("empty ENR in node identity")
+	}
+	return identity.Data.ENR, nil
+}
+
+var createNetworkOnce sync.Once
+
+func runDiscoveryTest(t *hivesim.T, c *hivesim.Client, node string) {
+	// Create a separate network to be able to send the client traffic from two separate IP addrs.
+	const network = "network1"
+	createNetworkOnce.Do(func() {
+		if err := t.Sim.CreateNetwork(t.SuiteID, network); err != nil {
+			t.Fatal("can't create network:", err)
+		}
+		if err := t.Sim.ConnectContainer(t.SuiteID, network, "simulation"); err != nil {
+			t.Fatal("can't connect simulation to network1:", err)
+		}
+	})
+
+	// Connect both simulation and client to this network.
+	if err := t.Sim.ConnectContainer(t.SuiteID, network, c.Container); err != nil {
+		t.Fatal("can't connect client to network1:", err)
+	}
+	// Find our IPs on the bridge network and network1.
+	bridgeIP, err := t.Sim.ContainerNetworkIP(t.SuiteID, "bridge", "simulation")
+	if err != nil {
+		t.Fatal("can't get IP of simulation container:", err)
+	}
+	net1IP, err := t.Sim.ContainerNetworkIP(t.SuiteID, network, "simulation")
+	if err != nil {
+		t.Fatal("can't get IP of simulation container on network1:", err)
+	}
+
+	// Run the test tool.
+	cmd := exec.Command("./devp2p", "discv5", "test", "--tap", "--listen1", bridgeIP, "--listen2", net1IP, node)
+	if err := runTAP(t, c.Type, cmd); err != nil {
+		t.Fatal(err)
+	}
+	// Run the tests which are not covered by the tool.
+	runV5Tests(t, c, node, bridgeIP)
+}
+
+func runTAP(t *hivesim.T, clientName string, cmd *exec.Cmd) error {
+	// Set up output streams.
+	cmd.Stderr = os.Stderr
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("can't set up test command stdout pipe: %v", err)
+	}
+	defer output.Close()
+
+	// Forward TAP output to the simulator log.
+	outputTee := io.TeeReader(output, os.Stdout)
+
+	// Run the test command.
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("can't start test command: %v", err)
+	}
+	if err := reportTAP(t, clientName, outputTee); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	return cmd.Wait()
+}
+
+func reportTAP(t *hivesim.T, clientName string, output io.Reader) error {
+	// Parse the output.
+	parser, err := tap.NewParser(output)
+	if err != nil {
+		return fmt.Errorf("error parsing TAP: %v", err)
+	}
+	// Forward results to hive.
+	for {
+		test, err := parser.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		name := fmt.Sprintf("%s (%s)", test.Description, clientName)
+		testID, err := t.Sim.StartTest(t.SuiteID, name, "")
+		if err != nil {
+			return fmt.Errorf("can't report sub-test result: %v", err)
+		}
+		result := hivesim.TestResult{Pass: test.Ok, Details: test.Diagnostic}
+		t.Sim.EndTest(t.SuiteID, testID, result)
+	}
+}
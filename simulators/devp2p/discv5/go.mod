@@ -0,0 +1,12 @@
+module github.com/ethereum/hive/simulators/devp2p/discv5
+
+go 1.16
+
+require (
+	github.com/ethereum/go-ethereum v1.10.4
+	github.com/ethereum/hive v0.0.0-20210901190357-cd81b24b2ac6
+	github.com/protolambda/bls12-381-util v0.0.0-20210812140640-b03868185758
+	github.com/protolambda/zrnt v0.20.0
+	github.com/protolambda/ztyp v0.1.9
+	github.com/shogo82148/go-tap v0.0.3
+)
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/hive/hivesim"
+)
+
+// These tests cover handshake edge cases and node record updates, which the devp2p
+// tool does not test. They run after the tool, against the same client.
+var v5tests = []struct {
+	name        string
+	description string
+	run         func(*hivesim.T, *v5conn)
+}{
+	{
+		name:        "ENRSeq",
+		description: "PONG must announce the sequence number of the client's node record, and FINDNODE with distance zero must return that record.",
+		run:         testENRSeq,
+	},
+	{
+		name: "ENRUpdate",
+		description: `The tester updates its node record and announces the new sequence number in PING.
+In the next handshake, WHOAREYOU must not request a newer record than the client has seen, and the
+client must accept the updated record.`,
+		run: testENRUpdate,
+	},
+	{
+		name: "StaleSession",
+		description: `The tester forgets its session keys and performs a new handshake. Packets encrypted with
+the old session keys must then be answered with WHOAREYOU, and the client must accept another handshake.`,
+		run: testStaleSession,
+	},
+	{
+		name:        "HandshakeWrongChallenge",
+		description: "The tester answers WHOAREYOU with a handshake signed over wrong challenge data. The client must not accept it.",
+		run:         testHandshakeWrongChallenge,
+	},
+}
+
+// runV5Tests runs the tests against a node. The tester listens on the given IP.
+func runV5Tests(t *hivesim.T, c *hivesim.Client, node, listenIP string) {
+	dest, err := enode.Parse(enode.ValidSchemes, node)
+	if err != nil {
+		t.Fatal("can't parse client node:", err)
+	}
+	for _, test := range v5tests {
+		test := test
+		t.Run(hivesim.TestSpec{
+			Name:        fmt.Sprintf("%s (%s)", test.name, c.Type),
+			Description: test.description,
+			Run: func(t *hivesim.T) {
+				conn, err := newV5Conn(dest, listenIP, t)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer conn.close()
+				test.run(t, conn)
+			},
+		})
+	}
+}
+
+// testENRSeq checks that the client serves the record announced in PONG.
+func testENRSeq(t *hivesim.T, c *v5conn) {
+	pong := c.ping(t)
+	if pong.ENRSeq < c.remote.Seq() {
+		t.Fatalf("PONG announces ENR seq %d, but the client's record has seq %d", pong.ENRSeq, c.remote.Seq())
+	}
+	nodes, err := c.findnode([]uint{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 {
+		t.Fatalf("FINDNODE with distance zero returned %d nodes, want 1", len(nodes))
+	}
+	if nodes[0].ID() != c.remote.ID() {
+		t.Fatalf("FINDNODE with distance zero returned node %v, want %v", nodes[0].ID(), c.remote.ID())
+	}
+	if nodes[0].Seq() != pong.ENRSeq {
+		t.Fatalf("FINDNODE with distance zero returned record with seq %d, PONG announced %d", nodes[0].Seq(), pong.ENRSeq)
+	}
+}
+
+// testENRUpdate checks that the client handles updates of the tester's record.
+func testENRUpdate(t *hivesim.T, c *v5conn) {
+	c.ping(t)
+	oldSeq := c.localNode.Node().Seq()
+
+	// Update the record and announce it on the existing session. The client may
+	// fetch the new record with FINDNODE, which is answered while waiting for PONG.
+	c.localNode.Set(enr.WithEntry("hive", uint(1)))
+	newSeq := c.localNode.Node().Seq()
+	if newSeq <= oldSeq {
+		t.Fatalf("local record seq did not increase (%d -> %d)", oldSeq, newSeq)
+	}
+	c.ping(t)
+	if c.servedSeq == newSeq {
+		t.Logf("client fetched the updated record")
+	}
+
+	// Start a new handshake. WHOAREYOU reports the record seq known by the client,
+	// which can't be newer than the records sent to it. Since it is older than the
+	// current record, the handshake includes the updated record.
+	c.resetSession()
+	ping := &v5wire.Ping{ReqID: c.nextReqID(), ENRSeq: newSeq}
+	nonce := c.write(ping, nil)
+	challenge, ok := c.read().(*v5wire.Whoareyou)
+	if !ok {
+		t.Fatal("expected WHOAREYOU for new session")
+	}
+	if challenge.Nonce != nonce {
+		t.Fatalf("wrong nonce %x in WHOAREYOU (want %x)", challenge.Nonce[:], nonce[:])
+	}
+	if challenge.RecordSeq > c.servedSeq {
+		t.Fatalf("WHOAREYOU requests ENR seq %d, but the highest seq sent to the client is %d", challenge.RecordSeq, c.servedSeq)
+	}
+	challenge.Node = c.remote
+	c.write(ping, challenge)
+	if challenge.RecordSeq < newSeq {
+		c.servedSeq = newSeq
+	}
+	if _, ok := c.read().(*v5wire.Pong); !ok {
+		t.Fatal("expected PONG after handshake with updated record")
+	}
+}
+
+// testStaleSession checks that the client switches to the keys of a new handshake.
+func testStaleSession(t *hivesim.T, c *v5conn) {
+	c.ping(t)
+	stale := c.codec
+
+	// Forget the session keys and create a new session.
+	c.resetSession()
+	c.ping(t)
+
+	// Send PING with the old keys. The client can't decrypt it and must respond with
+	// WHOAREYOU.
+	c.codec = stale
+	ping := &v5wire.Ping{ReqID: c.nextReqID(), ENRSeq: c.localNode.Node().Seq()}
+	nonce := c.write(ping, nil)
+	switch resp := c.read().(type) {
+	case *v5wire.Pong:
+		t.Fatal("client responded to PING encrypted with stale session keys")
+	case *v5wire.Whoareyou:
+		if resp.Nonce != nonce {
+			t.Fatalf("wrong nonce %x in WHOAREYOU (want %x)", resp.Nonce[:], nonce[:])
+		}
+		resp.Node = c.remote
+		c.write(ping, resp)
+	case nil:
+		t.Fatal("no WHOAREYOU for PING encrypted with stale session keys")
+	default:
+		t.Fatalf("expected WHOAREYOU, got %s", resp.Name())
+	}
+	if _, ok := c.read().(*v5wire.Pong); !ok {
+		t.Fatal("expected PONG after handshake")
+	}
+}
+
+// testHandshakeWrongChallenge checks that the client verifies the handshake signature.
+func testHandshakeWrongChallenge(t *hivesim.T, c *v5conn) {
+	ping := &v5wire.Ping{ReqID: c.nextReqID(), ENRSeq: c.localNode.Node().Seq()}
+	nonce := c.write(ping, nil)
+	challenge, ok := c.read().(*v5wire.Whoareyou)
+	if !ok {
+		t.Fatal("expected WHOAREYOU for new session")
+	}
+	if challenge.Nonce != nonce {
+		t.Fatalf("wrong nonce %x in WHOAREYOU (want %x)", challenge.Nonce[:], nonce[:])
+	}
+
+	// Modify the challenge data, which is part of the signed input and the key
+	// derivation.
+	challenge.Node = c.remote
+	challenge.ChallengeData = append([]byte{}, challenge.ChallengeData...)
+	challenge.ChallengeData[len(challenge.ChallengeData)-1] ^= 0xff
+	c.write(ping, challenge)
+	switch resp := c.read().(type) {
+	case *v5wire.Pong:
+		t.Fatal("client accepted handshake with wrong challenge data")
+	case nil, *v5wire.Whoareyou:
+		t.Logf("handshake was rejected")
+	default:
+		t.Fatalf("unexpected %s response to invalid handshake", resp.Name())
+	}
+
+	// The client must still accept a valid handshake.
+	c.resetSession()
+	c.ping(t)
+}
+
+// v5wait is the response timeout of the tests.
+const v5wait = time.Second
+
+// v5conn is a discv5 connection to the client.
+type v5conn struct {
+	localKey   *ecdsa.PrivateKey
+	localNode  *enode.LocalNode
+	remote     *enode.Node
+	remoteAddr *net.UDPAddr
+	codec      *v5wire.Codec
+	conn       net.PacketConn
+	log        *hivesim.T
+
+	reqID     uint32
+	servedSeq uint64 // highest seq of the local record sent to the client
+}
+
+func newV5Conn(dest *enode.Node, listenIP string, log *hivesim.T) (*v5conn, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	db, err := enode.OpenDB("")
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenPacket("udp", net.JoinHostPort(listenIP, "0"))
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	laddr := conn.LocalAddr().(*net.UDPAddr)
+	ln := enode.NewLocalNode(db, key)
+	ln.SetStaticIP(laddr.IP)
+	ln.SetFallbackUDP(laddr.Port)
+	c := &v5conn{
+		localKey:   key,
+		localNode:  ln,
+		remote:     dest,
+		remoteAddr: &net.UDPAddr{IP: dest.IP(), Port: dest.UDP()},
+		conn:       conn,
+		log:        log,
+	}
+	c.resetSession()
+	return c, nil
+}
+
+func (c *v5conn) close() {
+	c.conn.Close()
+	c.localNode.Database().Close()
+}
+
+// resetSession forgets all session keys.
+func (c *v5conn) resetSession() {
+	c.codec = v5wire.NewCodec(c.localNode, c.localKey, mclock.System{})
+}
+
+func (c *v5conn) nextReqID() []byte {
+	c.reqID++
+	id := make([]byte, 4)
+	binary.BigEndian.PutUint32(id, c.reqID)
+	return id
+}
+
+// ping sends PING, performing a handshake if requested, and waits for PONG.
+func (c *v5conn) ping(t *hivesim.T) *v5wire.Pong {
+	ping := &v5wire.Ping{ReqID: c.nextReqID(), ENRSeq: c.localNode.Node().Seq()}
+	pong, ok := c.request(ping).(*v5wire.Pong)
+	if !ok {
+		t.Fatal("expected PONG")
+	}
+	if !bytes.Equal(pong.ReqID, ping.ReqID) {
+		t.Fatalf("wrong request ID %x in PONG, want %x", pong.ReqID, ping.ReqID)
+	}
+	return pong
+}
+
+// findnode sends FINDNODE and collects the nodes of all responses.
+func (c *v5conn) findnode(dists []uint) ([]*enode.Node, error) {
+	req := &v5wire.Findnode{ReqID: c.nextReqID(), Distances: dists}
+	resp := c.request(req)
+	var nodes []*enode.Node
+	for received := 1; ; received++ {
+		nodesResp, ok := resp.(*v5wire.Nodes)
+		if !ok {
+			return nil, fmt.Errorf("expected NODES")
+		}
+		if !bytes.Equal(nodesResp.ReqID, req.ReqID) {
+			return nil, fmt.Errorf("wrong request ID %x in NODES, want %x", nodesResp.ReqID, req.ReqID)
+		}
+		for _, r := range nodesResp.Nodes {
+			n, err := enode.New(enode.ValidSchemes, r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid record in NODES: %v", err)
+			}
+			nodes = append(nodes, n)
+		}
+		if received >= int(nodesResp.Total) {
+			return nodes, nil
+		}
+		resp = c.read()
+	}
+}
+
+// request sends a request and returns the first response. If the client responds
+// with WHOAREYOU, the handshake is performed and the request is sent again.
+func (c *v5conn) request(req v5wire.Packet) v5wire.Packet {
+	nonce := c.write(req, nil)
+	resp := c.read()
+	challenge, ok := resp.(*v5wire.Whoareyou)
+	if !ok {
+		return resp
+	}
+	if challenge.Nonce != nonce {
+		c.log.Logf("wrong nonce %x in WHOAREYOU (want %x)", challenge.Nonce[:], nonce[:])
+		return nil
+	}
+	challenge.Node = c.remote
+	c.write(req, challenge)
+	if challenge.RecordSeq < c.localNode.Node().Seq() {
+		c.servedSeq = c.localNode.Node().Seq()
+	}
+	return c.read()
+}
+
+// write sends a packet. If challenge is set, it is sent as a handshake.
+func (c *v5conn) write(p v5wire.Packet, challenge *v5wire.Whoareyou) v5wire.Nonce {
+	packet, nonce, err := c.codec.Encode(c.remote.ID(), c.remoteAddr.String(), p, challenge)
+	if err != nil {
+		c.log.Logf("can't encode %s: %v", p.Name(), err)
+		return nonce
+	}
+	if _, err := c.conn.WriteTo(packet, c.remoteAddr); err != nil {
+		c.log.Logf("can't send %s: %v", p.Name(), err)
+	} else {
+		c.log.Logf(">> %s", p.Name())
+	}
+	return nonce
+}
+
+// read waits for the next response. Requests of the client are answered while waiting.
+// It returns nil if no response is received.
+func (c *v5conn) read() v5wire.Packet {
+	buf := make([]byte, 1280)
+	deadline := time.Now().Add(v5wait)
+	for {
+		c.conn.SetReadDeadline(deadline)
+		n, from, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			if !netutil.IsTimeout(err) {
+				c.log.Logf("read error: %v", err)
+			}
+			return nil
+		}
+		_, _, p, err := c.codec.Decode(buf[:n], from.String())
+		if err != nil {
+			c.log.Logf("can't decode packet: %v", err)
+			continue
+		}
+		c.log.Logf("<< %s", p.Name())
+		switch p := p.(type) {
+		case *v5wire.Ping:
+			c.write(&v5wire.Pong{ReqID: p.ReqID, ENRSeq: c.localNode.Node().Seq(), ToIP: c.remoteAddr.IP, ToPort: uint16(c.remoteAddr.Port)}, nil)
+		case *v5wire.Findnode:
+			c.handleFindnode(p)
+		case *v5wire.Unknown:
+			// The client has no session, ignore its request.
+		default:
+			return p
+		}
+	}
+}
+
+// handleFindnode answers FINDNODE with the local record for distance zero.
+func (c *v5conn) handleFindnode(p *v5wire.Findnode) {
+	resp := &v5wire.Nodes{ReqID: p.ReqID, Total: 1}
+	for _, d := range p.Distances {
+		if d == 0 {
+			n := c.localNode.Node()
+			resp.Nodes = append(resp.Nodes, n.Record())
+			if n.Seq() > c.servedSeq {
+				c.servedSeq = n.Seq()
+			}
+		}
+	}
+	c.write(resp, nil)
+}
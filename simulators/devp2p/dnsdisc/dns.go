@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dnsServer is an authoritative DNS server which serves the TXT records of the
+// ENR trees published by the tests. It answers queries for any name.
+type dnsServer struct {
+	udp net.PacketConn
+	tcp net.Listener
+	wg  sync.WaitGroup
+
+	mu      sync.Mutex
+	records map[string]string // TXT record content by lowercase name
+	queries map[string]int    // number of queries received for each name
+}
+
+// startDNSServer launches the server on the given UDP and TCP address.
+func startDNSServer(addr string) (*dnsServer, error) {
+	udp, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	tcp, err := net.Listen("tcp", addr)
+	if err != nil {
+		udp.Close()
+		return nil, err
+	}
+	s := &dnsServer{
+		udp:     udp,
+		tcp:     tcp,
+		records: make(map[string]string),
+		queries: make(map[string]int),
+	}
+	s.wg.Add(2)
+	go s.serveUDP()
+	go s.serveTCP()
+	return s, nil
+}
+
+// close stops the server.
+func (s *dnsServer) close() {
+	s.udp.Close()
+	s.tcp.Close()
+	s.wg.Wait()
+}
+
+// addRecords publishes TXT records.
+func (s *dnsServer) addRecords(records map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, txt := range records {
+		s.records[strings.ToLower(name)] = txt
+	}
+}
+
+// queried reports whether the server has received a query for the given name.
+func (s *dnsServer) queried(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries[strings.ToLower(name)] > 0
+}
+
+func (s *dnsServer) serveUDP() {
+	defer s.wg.Done()
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		resp, err := s.handleQuery(buf[:n])
+		if err != nil {
+			continue
+		}
+		s.udp.WriteTo(resp, addr)
+	}
+}
+
+func (s *dnsServer) serveTCP() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				continue
+			}
+			return
+		}
+		go s.handleTCP(conn)
+	}
+}
+
+// handleTCP serves queries on a TCP connection. Messages are prefixed by their
+// two-byte length.
+func (s *dnsServer) handleTCP(conn net.Conn) {
+	defer conn.Close()
+	var size [2]byte
+	for {
+		conn.SetDeadline(time.Now().Add(10 * time.Second))
+		if _, err := io.ReadFull(conn, size[:]); err != nil {
+			return
+		}
+		query := make([]byte, binary.BigEndian.Uint16(size[:]))
+		if _, err := io.ReadFull(conn, query); err != nil {
+			return
+		}
+		resp, err := s.handleQuery(query)
+		if err != nil {
+			return
+		}
+		binary.BigEndian.PutUint16(size[:], uint16(len(resp)))
+		if _, err := conn.Write(append(size[:], resp...)); err != nil {
+			return
+		}
+	}
+}
+
+// handleQuery creates the response to a DNS query. Names without a TXT record are
+// answered with NXDOMAIN.
+func (s *dnsServer) handleQuery(query []byte) ([]byte, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return nil, err
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil, err
+	}
+	name := strings.ToLower(strings.TrimSuffix(q.Name.String(), "."))
+	s.mu.Lock()
+	s.queries[name]++
+	txt, found := s.records[name]
+	s.mu.Unlock()
+
+	rh := dnsmessage.Header{
+		ID:               h.ID,
+		Response:         true,
+		OpCode:           h.OpCode,
+		Authoritative:    true,
+		RecursionDesired: h.RecursionDesired,
+	}
+	if !found {
+		rh.RCode = dnsmessage.RCodeNameError
+	}
+	b := dnsmessage.NewBuilder(nil, rh)
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+	if found && q.Type == dnsmessage.TypeTXT {
+		if err := b.StartAnswers(); err != nil {
+			return nil, err
+		}
+		hdr := dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET}
+		if err := b.TXTResource(hdr, dnsmessage.TXTResource{TXT: splitTXT(txt)}); err != nil {
+			return nil, err
+		}
+	}
+	return b.Finish()
+}
+
+// splitTXT splits record content into character-strings of at most 255 bytes.
+// Resolvers join the strings of a TXT record.
+func splitTXT(txt string) []string {
+	var parts []string
+	for len(txt) > 255 {
+		parts = append(parts, txt[:255])
+		txt = txt[255:]
+	}
+	return append(parts, txt)
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/hive/hivesim"
+)
+
+const (
+	networkID = 19763
+	chainID   = 19763
+)
+
+const (
+	// This is how long the client may take to dial all nodes.
+	discoveryTimeout = 60 * time.Second
+	// This is how long the test watches for dials of nodes which must not be dialed,
+	// after all expected dials and DNS queries have happened.
+	quietPeriod = 10 * time.Second
+)
+
+var clientParams = hivesim.Params{
+	"HIVE_NETWORK_ID":          fmt.Sprint(networkID),
+	"HIVE_CHAIN_ID":            fmt.Sprint(chainID),
+	"HIVE_FORK_HOMESTEAD":      "0",
+	"HIVE_FORK_TANGERINE":      "0",
+	"HIVE_FORK_SPURIOUS":       "0",
+	"HIVE_FORK_BYZANTIUM":      "0",
+	"HIVE_FORK_CONSTANTINOPLE": "0",
+	"HIVE_FORK_PETERSBURG":     "0",
+	"HIVE_FORK_ISTANBUL":       "0",
+	"HIVE_FORK_BERLIN":         "0",
+	"HIVE_LOGLEVEL":            "4",
+}
+
+// dnsCase is a DNS discovery test. The client is pointed at the first tree.
+type dnsCase struct {
+	name        string
+	description string
+	trees       []treeSpec
+}
+
+var cases = []dnsCase{
+	{
+		name:        "single tree",
+		description: "The client must dial all nodes of a small tree.",
+		trees:       []treeSpec{{nodes: 3}},
+	},
+	{
+		name:        "large tree",
+		description: "The client must dial all nodes of a tree with several levels of branch entries.",
+		trees:       []treeSpec{{nodes: 20}},
+	},
+	{
+		name:        "linked trees",
+		description: "The client must follow links to other trees and dial the nodes of all linked trees.",
+		trees: []treeSpec{
+			{nodes: 2, links: []int{1}},
+			{nodes: 2, links: []int{2}},
+			{nodes: 2},
+		},
+	},
+	{
+		name:        "wrong signature",
+		description: "The tree root is not signed by the key in the tree URL. The client must not dial any node of the tree.",
+		trees:       []treeSpec{{nodes: 3, badSig: true}},
+	},
+	{
+		name:        "link to tree with wrong signature",
+		description: "The linked tree is not signed by the key in the link. The client must dial the nodes of the first tree, but not those of the linked tree.",
+		trees: []treeSpec{
+			{nodes: 2, links: []int{1}},
+			{nodes: 2, badSig: true},
+		},
+	},
+	{
+		name:        "link to missing tree",
+		description: "The linked tree does not exist. The client must still dial the nodes of the first tree.",
+		trees: []treeSpec{
+			{nodes: 2, links: []int{1}},
+			{missing: true},
+		},
+	},
+}
+
+func main() {
+	suite := hivesim.Suite{
+		Name: "dnsdisc",
+		Description: `This suite checks node discovery via DNS (EIP-1459). The simulator runs a DNS
+server which serves ENR trees of nodes run by the simulator. Clients are pointed at a tree
+using HIVE_DNS_DISCOVERY and must dial the nodes of valid trees. Nodes of trees with
+invalid signatures must not be dialed. Only clients with the eth1_dns_discovery role
+are tested.`,
+	}
+	suite.Add(hivesim.TestSpec{
+		Name:        "dns discovery",
+		Description: "This test runs the DNS discovery checks for all clients with the eth1_dns_discovery role.",
+		Run:         runAllTests,
+	})
+	hivesim.MustRunSuite(hivesim.New(), suite)
+}
+
+// testEnv is the shared state of all tests.
+type testEnv struct {
+	dns       *dnsServer
+	ip        net.IP
+	forkID    forkid.ID
+	genesisJS []byte
+
+	mu      sync.Mutex
+	domains int
+}
+
+// newDomain returns a domain name which is not used by any other tree.
+func (env *testEnv) newDomain() string {
+	env.mu.Lock()
+	defer env.mu.Unlock()
+	env.domains++
+	return fmt.Sprintf("tree%d.nodes.hive", env.domains)
+}
+
+func runAllTests(t *hivesim.T) {
+	ip, err := t.Sim.ContainerNetworkIP(t.SuiteID, "bridge", "simulation")
+	if err != nil {
+		t.Fatal("can't get IP of simulation container:", err)
+	}
+	env := &testEnv{ip: net.ParseIP(ip)}
+	if env.ip == nil {
+		t.Fatalf("invalid simulation container IP %q", ip)
+	}
+	if env.genesisJS, env.forkID, err = makeGenesis(); err != nil {
+		t.Fatal("can't create genesis:", err)
+	}
+	if env.dns, err = startDNSServer(":53"); err != nil {
+		t.Fatal("can't start DNS server:", err)
+	}
+	defer env.dns.close()
+
+	clients, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatal("can't get client types:", err)
+	}
+	for _, client := range clients {
+		if !client.HasRole("eth1_dns_discovery") {
+			continue
+		}
+		for _, c := range cases {
+			clientName, c := client.Name, c
+			t.Run(hivesim.TestSpec{
+				Name:        fmt.Sprintf("%s (%s)", c.name, clientName),
+				Description: c.description,
+				Run:         func(t *hivesim.T) { runCase(t, env, clientName, &c) },
+			})
+		}
+	}
+}
+
+func runCase(t *hivesim.T, env *testEnv, clientName string, c *dnsCase) {
+	trees, err := publishTrees(env, c.trees)
+	defer func() {
+		for _, tt := range trees {
+			for _, n := range tt.nodes {
+				n.close()
+			}
+		}
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, unwanted, roots := expectations(trees)
+
+	params := clientParams.Set("HIVE_DNS_DISCOVERY", trees[0].url)
+	params = params.Set("HIVE_DNS_RESOLVER", env.ip.String())
+	t.StartClient(clientName, params, hivesim.WithDynamicFile("/genesis.json", bytesSource(env.genesisJS)))
+
+	// Wait for the expected dials.
+	deadline := time.Now().Add(discoveryTimeout)
+	for time.Now().Before(deadline) && !(allDialed(want) && allQueried(env.dns, roots)) {
+		time.Sleep(200 * time.Millisecond)
+	}
+	for _, root := range roots {
+		if !env.dns.queried(root) {
+			t.Errorf("client did not query the root of tree %s", root)
+		}
+	}
+	for _, n := range want {
+		dialed, err := n.status()
+		switch {
+		case dialed:
+			t.Logf("node %v was dialed", n)
+		case err != nil:
+			t.Errorf("node %v was not dialed, last handshake failed: %v", n, err)
+		default:
+			t.Errorf("node %v was not dialed", n)
+		}
+	}
+
+	// Check that nodes of invalid trees are not dialed.
+	if len(unwanted) == 0 {
+		return
+	}
+	time.Sleep(quietPeriod)
+	for _, n := range unwanted {
+		if dialed, _ := n.status(); dialed {
+			t.Errorf("node %v of an invalid tree was dialed", n)
+		}
+	}
+}
+
+func allDialed(nodes []*testNode) bool {
+	for _, n := range nodes {
+		if dialed, _ := n.status(); !dialed {
+			return false
+		}
+	}
+	return true
+}
+
+func allQueried(dns *dnsServer, names []string) bool {
+	for _, name := range names {
+		if !dns.queried(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// makeGenesis creates the genesis block of the client and returns the fork ID of the
+// chain. All forks are enabled at genesis, matching clientParams.
+func makeGenesis() ([]byte, forkid.ID, error) {
+	config := &params.ChainConfig{
+		ChainID:             big.NewInt(chainID),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		IstanbulBlock:       big.NewInt(0),
+		BerlinBlock:         big.NewInt(0),
+		Ethash:              new(params.EthashConfig),
+	}
+	genesis := &core.Genesis{
+		Config:     config,
+		Difficulty: big.NewInt(131072),
+		GasLimit:   5000000,
+		Timestamp:  1600000000,
+		ExtraData:  []byte("hive DNS discovery test"),
+		Alloc:      core.GenesisAlloc{},
+	}
+	genesisJS, err := json.Marshal(genesis)
+	if err != nil {
+		return nil, forkid.ID{}, err
+	}
+	id := forkid.NewID(config, genesis.ToBlock(nil).Hash(), 0)
+	return genesisJS, id, nil
+}
+
+func bytesSource(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ethEntry is the 'eth' ENR entry which advertises the eth protocol.
+type ethEntry struct {
+	ForkID forkid.ID
+	Rest   []rlp.RawValue `rlp:"tail"`
+}
+
+func (ethEntry) ENRKey() string { return "eth" }
+
+// testNode is a node listed in an ENR tree. It accepts TCP connections and performs
+// the RLPx handshake to check that the client dials it with the correct node key.
+type testNode struct {
+	key      *ecdsa.PrivateKey
+	node     *enode.Node
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu           sync.Mutex
+	dialed       bool
+	handshakeErr error // last failed handshake
+}
+
+// startTestNode creates a node listening on the given IP.
+func startTestNode(ip net.IP, id forkid.ID) (*testNode, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		return nil, err
+	}
+	var r enr.Record
+	r.Set(enr.IP(ip))
+	r.Set(enr.TCP(listener.Addr().(*net.TCPAddr).Port))
+	r.Set(ethEntry{ForkID: id})
+	if err := enode.SignV4(&r, key); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	node, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	n := &testNode{key: key, node: node, listener: listener}
+	n.wg.Add(1)
+	go n.accept()
+	return n, nil
+}
+
+// close stops the listener.
+func (n *testNode) close() {
+	n.listener.Close()
+	n.wg.Wait()
+}
+
+func (n *testNode) accept() {
+	defer n.wg.Done()
+	for {
+		fd, err := n.listener.Accept()
+		if err != nil {
+			return
+		}
+		n.handshake(fd)
+	}
+}
+
+// handshake performs the RLPx handshake on an inbound connection. The client is
+// counted as having dialed the node only if the handshake succeeds, which proves it
+// used the public key from the node's ENR.
+func (n *testNode) handshake(fd net.Conn) {
+	conn := rlpx.NewConn(fd, nil)
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	_, err := conn.Handshake(n.key)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err != nil {
+		n.handshakeErr = err
+	} else {
+		n.dialed = true
+	}
+}
+
+// status returns whether the node was dialed and the last handshake error.
+func (n *testNode) status() (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.dialed, n.handshakeErr
+}
+
+func (n *testNode) String() string {
+	return fmt.Sprintf("%x (%v:%d)", n.node.ID().Bytes()[:8], n.node.IP(), n.node.TCP())
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/base32"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/dnsdisc"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// treeSpec describes an ENR tree of a test case.
+type treeSpec struct {
+	nodes   int   // number of nodes in the tree
+	links   []int // indexes of linked trees
+	badSig  bool  // root is signed with a key which doesn't match the tree URL
+	missing bool  // tree is not published
+}
+
+// testTree is an ENR tree published on the DNS server.
+type testTree struct {
+	spec   treeSpec
+	domain string
+	url    string // enrtree:// URL, as used in links
+	nodes  []*testNode
+}
+
+// publishTrees creates the nodes of all trees and publishes the trees on the DNS
+// server. The client is pointed at the first tree.
+func publishTrees(env *testEnv, specs []treeSpec) ([]*testTree, error) {
+	trees := make([]*testTree, len(specs))
+	keys := make([]*ecdsa.PrivateKey, len(specs))
+	for i, spec := range specs {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return trees, err
+		}
+		domain := env.newDomain()
+		keys[i] = key
+		trees[i] = &testTree{spec: spec, domain: domain, url: treeURL(&key.PublicKey, domain)}
+		for j := 0; j < spec.nodes; j++ {
+			n, err := startTestNode(env.ip, env.forkID)
+			if err != nil {
+				return trees, fmt.Errorf("can't start node: %v", err)
+			}
+			trees[i].nodes = append(trees[i].nodes, n)
+		}
+	}
+
+	// The URLs of all trees are known, so the links can be created.
+	for i, tt := range trees {
+		if tt.spec.missing {
+			continue
+		}
+		var nodes []*enode.Node
+		for _, n := range tt.nodes {
+			nodes = append(nodes, n.node)
+		}
+		var links []string
+		for _, l := range tt.spec.links {
+			links = append(links, trees[l].url)
+		}
+		tree, err := dnsdisc.MakeTree(1, nodes, links)
+		if err != nil {
+			return trees, fmt.Errorf("can't create tree: %v", err)
+		}
+		signer := keys[i]
+		if tt.spec.badSig {
+			if signer, err = crypto.GenerateKey(); err != nil {
+				return trees, err
+			}
+		}
+		if _, err := tree.Sign(signer, tt.domain); err != nil {
+			return trees, fmt.Errorf("can't sign tree: %v", err)
+		}
+		env.dns.addRecords(tree.ToTXT(tt.domain))
+	}
+	return trees, nil
+}
+
+// treeURL creates the enrtree:// URL of a tree.
+func treeURL(pubkey *ecdsa.PublicKey, domain string) string {
+	b32 := base32.StdEncoding.WithPadding(base32.NoPadding)
+	return "enrtree://" + b32.EncodeToString(crypto.CompressPubkey(pubkey)) + "@" + domain
+}
+
+// expectations computes the outcome of discovery from the first tree. Nodes of valid
+// trees reachable through links must be dialed, all others must not. The roots of all
+// reachable trees must be queried, including the invalid ones.
+func expectations(trees []*testTree) (want, unwanted []*testNode, roots []string) {
+	reached := make(map[int]bool)
+	queue := []int{0}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		if reached[i] {
+			continue
+		}
+		reached[i] = true
+		tt := trees[i]
+		roots = append(roots, tt.domain)
+		if tt.spec.missing || tt.spec.badSig {
+			continue
+		}
+		want = append(want, tt.nodes...)
+		queue = append(queue, tt.spec.links...)
+	}
+	for i, tt := range trees {
+		if !reached[i] || tt.spec.missing || tt.spec.badSig {
+			unwanted = append(unwanted, tt.nodes...)
+		}
+	}
+	return want, unwanted, roots
+}
@@ -34,7 +34,9 @@ Results from the test tool are reported as individual sub-tests.`,
 		},
 		Run: runEthTest,
 	})
-	hivesim.MustRunSuite(hivesim.New(), suite)
+	sim := hivesim.New()
+	hivesim.MustRunSuite(sim, suite)
+	hivesim.MustRunSuite(sim, snapSuite)
 }
 
 func runEthTest(t *hivesim.T, c *hivesim.Client) {
@@ -92,6 +94,6 @@ func reportTAP(t *hivesim.T, clientName string, output io.Reader) error {
 			return fmt.Errorf("can't report sub-test result: %v", err)
 		}
 		result := hivesim.TestResult{Pass: test.Ok, Details: test.Diagnostic}
-		t.Sim.EndTest(t.SuiteID, testID, result, nil)
+		t.Sim.EndTest(t.SuiteID, testID, result)
 	}
 }
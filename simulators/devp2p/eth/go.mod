@@ -3,6 +3,6 @@ module github.com/ethereum/hive/simulators/devp2p/eth
 go 1.13
 
 require (
-	github.com/ethereum/hive v0.0.0-20201104115140-35ee289d2156
+	github.com/ethereum/hive v0.0.0-20210901190357-cd81b24b2ac6
 	github.com/shogo82148/go-tap v0.0.3
 )
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os/exec"
+
+	"github.com/ethereum/hive/hivesim"
+)
+
+// snapSuite tests the snap protocol. Unlike the eth protocol tests, the client
+// imports the full chain up front, so that it can serve the state of the head block.
+var snapSuite = hivesim.Suite{
+	Name:        "snap protocol",
+	Description: "This suite tests a client's ability to serve state using the snap protocol.",
+}
+
+func init() {
+	snapSuite.Add(hivesim.ClientTestSpec{
+		Role: "eth1_snap_sync",
+		Name: "client launch",
+		Description: `This test launches the client and runs the snap tests of the test tool.
+The tests check GetAccountRange, GetStorageRanges, GetByteCodes and GetTrieNodes
+responses, including proofs and response limits.
+Results from the test tool are reported as individual sub-tests.`,
+		Parameters: hivesim.Params{
+			"HIVE_NETWORK_ID":     "19763",
+			"HIVE_CHAIN_ID":       "19763",
+			"HIVE_FORK_HOMESTEAD": "0",
+			"HIVE_FORK_TANGERINE": "0",
+			"HIVE_FORK_SPURIOUS":  "0",
+			"HIVE_FORK_BYZANTIUM": "0",
+			"HIVE_LOGLEVEL":       "4",
+		},
+		Files: map[string]string{
+			"genesis.json": "./init/genesis.json",
+			"chain.rlp":    "./init/fullchain.rlp",
+		},
+		Run: runSnapTest,
+	})
+}
+
+func runSnapTest(t *hivesim.T, c *hivesim.Client) {
+	enode, err := c.EnodeURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("/devp2p", "rlpx", "snap-test", "--tap", enode, "./init/fullchain.rlp", "./init/genesis.json")
+	if err := runTAP(t, c.Type, cmd); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/hive/hivesim"
+)
+
+const (
+	networkID = 19763
+	chainID   = 19763
+)
+
+// forkParams lists the client parameters of all supported forks in activation order.
+var forkParams = []string{
+	"HIVE_FORK_HOMESTEAD",
+	"HIVE_FORK_TANGERINE",
+	"HIVE_FORK_SPURIOUS",
+	"HIVE_FORK_BYZANTIUM",
+	"HIVE_FORK_CONSTANTINOPLE",
+	"HIVE_FORK_PETERSBURG",
+	"HIVE_FORK_ISTANBUL",
+	"HIVE_FORK_MUIR_GLACIER",
+	"HIVE_FORK_BERLIN",
+	"HIVE_FORK_LONDON",
+}
+
+// schedule is a fork configuration. The client is started with a chain of 'head'
+// blocks, so forks at or below head have passed and forks above head are upcoming.
+type schedule struct {
+	name  string
+	forks []uint64 // activation blocks, indexed like forkParams
+	head  int
+}
+
+var schedules = []schedule{
+	{
+		name:  "all forks at genesis",
+		forks: []uint64{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		head:  10,
+	},
+	{
+		name:  "all forks passed",
+		forks: []uint64{1, 2, 3, 4, 5, 5, 6, 6, 7, 8},
+		head:  10,
+	},
+	{
+		name:  "some forks upcoming",
+		forks: []uint64{0, 0, 0, 2, 4, 4, 6, 6, 20, 30},
+		head:  10,
+	},
+	{
+		name:  "all forks upcoming",
+		forks: []uint64{20, 30, 40, 50, 60, 60, 70, 70, 80, 90},
+		head:  10,
+	},
+}
+
+// params returns the client parameters of the schedule.
+func (s *schedule) params() hivesim.Params {
+	p := hivesim.Params{
+		"HIVE_NETWORK_ID": fmt.Sprint(networkID),
+		"HIVE_CHAIN_ID":   fmt.Sprint(chainID),
+		"HIVE_SKIP_POW":   "1",
+		"HIVE_LOGLEVEL":   "4",
+	}
+	for i, name := range forkParams {
+		p[name] = fmt.Sprint(s.forks[i])
+	}
+	return p
+}
+
+// chainConfig returns the chain configuration of the schedule.
+func (s *schedule) chainConfig() *params.ChainConfig {
+	b := func(i int) *big.Int { return new(big.Int).SetUint64(s.forks[i]) }
+	return &params.ChainConfig{
+		ChainID:             big.NewInt(chainID),
+		HomesteadBlock:      b(0),
+		EIP150Block:         b(1),
+		EIP155Block:         b(2),
+		EIP158Block:         b(2),
+		ByzantiumBlock:      b(3),
+		ConstantinopleBlock: b(4),
+		PetersburgBlock:     b(5),
+		IstanbulBlock:       b(6),
+		MuirGlacierBlock:    b(7),
+		BerlinBlock:         b(8),
+		LondonBlock:         b(9),
+		Ethash:              new(params.EthashConfig),
+	}
+}
+
+// forkBlocks returns the distinct non-zero fork blocks in ascending order. This is
+// the list of forks which contribute to the fork ID.
+func (s *schedule) forkBlocks() []uint64 {
+	var list []uint64
+	seen := make(map[uint64]bool)
+	for _, f := range s.forks {
+		if f != 0 && !seen[f] {
+			seen[f] = true
+			list = append(list, f)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// testChain is the chain served by the client.
+type testChain struct {
+	config    *params.ChainConfig
+	genesis   *core.Genesis
+	blocks    []*types.Block // blocks[0] is the genesis block
+	genesisJS []byte
+	chainRLP  []byte
+}
+
+// makeChain generates a chain of empty blocks following the fork schedule. The blocks
+// have correct difficulty but no valid proof-of-work, so clients must be started with
+// HIVE_SKIP_POW.
+func makeChain(s *schedule) (*testChain, error) {
+	config := s.chainConfig()
+	genesis := &core.Genesis{
+		Config:     config,
+		Difficulty: big.NewInt(131072),
+		GasLimit:   5000000,
+		Timestamp:  1600000000,
+		ExtraData:  []byte("hive fork ID test"),
+		Alloc:      core.GenesisAlloc{},
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesisBlock := genesis.MustCommit(db)
+	blocks, _ := core.GenerateChain(config, genesisBlock, ethash.NewFaker(), db, s.head, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{0x01})
+	})
+
+	genesisJS, err := json.Marshal(genesis)
+	if err != nil {
+		return nil, err
+	}
+	var chainRLP bytes.Buffer
+	for _, b := range blocks {
+		if err := rlp.Encode(&chainRLP, b); err != nil {
+			return nil, err
+		}
+	}
+	return &testChain{
+		config:    config,
+		genesis:   genesis,
+		blocks:    append([]*types.Block{genesisBlock}, blocks...),
+		genesisJS: genesisJS,
+		chainRLP:  chainRLP.Bytes(),
+	}, nil
+}
+
+// Genesis returns the genesis block.
+func (c *testChain) Genesis() *types.Block {
+	return c.blocks[0]
+}
+
+// Config returns the chain configuration.
+func (c *testChain) Config() *params.ChainConfig {
+	return c.config
+}
+
+// CurrentHeader returns the header of the last block.
+func (c *testChain) CurrentHeader() *types.Header {
+	return c.blocks[len(c.blocks)-1].Header()
+}
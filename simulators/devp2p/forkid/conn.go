@@ -0,0 +1,197 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// devp2p base protocol message codes.
+const (
+	helloMsg = 0x00
+	discMsg  = 0x01
+	pingMsg  = 0x02
+	pongMsg  = 0x03
+
+	baseProtocolLength = 16
+	ethStatusMsg       = baseProtocolLength + 0x00
+)
+
+// These are the eth protocol versions supported by the test. Their Status messages
+// are identical.
+var ethCaps = []p2p.Cap{{Name: "eth", Version: 65}, {Name: "eth", Version: 66}}
+
+// hello is the devp2p handshake message.
+type hello struct {
+	Version    uint64
+	Name       string
+	Caps       []p2p.Cap
+	ListenPort uint64
+	ID         []byte
+	Rest       []rlp.RawValue `rlp:"tail"`
+}
+
+// status is the eth protocol handshake message.
+type status struct {
+	ProtocolVersion uint32
+	NetworkID       uint64
+	TD              *big.Int
+	Head            common.Hash
+	Genesis         common.Hash
+	ForkID          forkid.ID
+}
+
+// handshakeResult is the outcome of a Status exchange.
+type handshakeResult struct {
+	remoteStatus *status // nil if the client disconnected before sending Status
+	disconnected bool
+	reason       string
+}
+
+var errTimeout = errors.New("timeout")
+
+// statusHandshake connects to the node and performs the eth Status handshake,
+// announcing the given fork ID. It then waits for the given duration to see whether
+// the node disconnects.
+func statusHandshake(node *enode.Node, chain *testChain, id forkid.ID, wait time.Duration) (*handshakeResult, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	fd, err := net.DialTimeout("tcp", fmt.Sprintf("%v:%d", node.IP(), node.TCP()), 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	conn := rlpx.NewConn(fd, node.Pubkey())
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Handshake(key); err != nil {
+		return nil, fmt.Errorf("RLPx handshake failed: %v", err)
+	}
+	version, err := exchangeHello(conn, key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send our Status. The client sends its own Status without waiting for ours,
+	// so the messages may cross.
+	genesis := chain.Genesis()
+	ourStatus := &status{
+		ProtocolVersion: uint32(version),
+		NetworkID:       networkID,
+		TD:              genesis.Difficulty(),
+		Head:            genesis.Hash(),
+		Genesis:         genesis.Hash(),
+		ForkID:          id,
+	}
+	if err := writeMsg(conn, ethStatusMsg, ourStatus); err != nil {
+		return nil, fmt.Errorf("can't send Status: %v", err)
+	}
+
+	// Read messages until the client disconnects or the wait time is over.
+	result := new(handshakeResult)
+	conn.SetDeadline(time.Now().Add(wait))
+	for {
+		code, data, err := readMsg(conn)
+		switch {
+		case err == errTimeout:
+			if result.remoteStatus == nil {
+				return nil, errors.New("client did not send Status")
+			}
+			return result, nil
+		case err != nil:
+			result.disconnected = true
+			result.reason = err.Error()
+			return result, nil
+		}
+		switch code {
+		case discMsg:
+			var reason []p2p.DiscReason
+			rlp.DecodeBytes(data, &reason)
+			result.disconnected = true
+			result.reason = "connection closed"
+			if len(reason) > 0 {
+				result.reason = reason[0].String()
+			}
+			return result, nil
+		case pingMsg:
+			writeMsg(conn, pongMsg, []interface{}{})
+		case ethStatusMsg:
+			result.remoteStatus = new(status)
+			if err := rlp.DecodeBytes(data, result.remoteStatus); err != nil {
+				return nil, fmt.Errorf("invalid Status from client: %v", err)
+			}
+		}
+	}
+}
+
+// exchangeHello performs the devp2p protocol handshake and returns the negotiated
+// eth protocol version.
+func exchangeHello(conn *rlpx.Conn, key *ecdsa.PrivateKey) (uint, error) {
+	pub := crypto.FromECDSAPub(&key.PublicKey)[1:]
+	ours := &hello{Version: 5, Name: "hive", Caps: ethCaps, ID: pub}
+	if err := writeMsg(conn, helloMsg, ours); err != nil {
+		return 0, fmt.Errorf("can't send Hello: %v", err)
+	}
+	code, data, err := readMsg(conn)
+	if err != nil {
+		return 0, fmt.Errorf("can't read Hello: %v", err)
+	}
+	switch code {
+	case helloMsg:
+	case discMsg:
+		var reason []p2p.DiscReason
+		rlp.DecodeBytes(data, &reason)
+		return 0, fmt.Errorf("client disconnected during protocol handshake: %v", reason)
+	default:
+		return 0, fmt.Errorf("expected Hello, got message code %d", code)
+	}
+	var theirs hello
+	if err := rlp.DecodeBytes(data, &theirs); err != nil {
+		return 0, fmt.Errorf("invalid Hello: %v", err)
+	}
+	// Messages after Hello are compressed if both sides support devp2p v5.
+	conn.SetSnappy(theirs.Version >= 5)
+
+	var version uint
+	for _, c := range theirs.Caps {
+		for _, our := range ethCaps {
+			if c == our && our.Version > version {
+				version = our.Version
+			}
+		}
+	}
+	if version == 0 {
+		return 0, fmt.Errorf("no matching eth protocol version in %v", theirs.Caps)
+	}
+	return version, nil
+}
+
+func writeMsg(conn *rlpx.Conn, code uint64, msg interface{}) error {
+	data, err := rlp.EncodeToBytes(msg)
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(code, data)
+	return err
+}
+
+func readMsg(conn *rlpx.Conn) (uint64, []byte, error) {
+	code, data, _, err := conn.Read()
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return 0, nil, errTimeout
+	}
+	return code, data, err
+}
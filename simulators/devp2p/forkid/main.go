@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/hive/hivesim"
+)
+
+// This is how long the test waits for a disconnect after sending Status.
+const disconnectWait = 3 * time.Second
+
+func main() {
+	suite := hivesim.Suite{
+		Name: "forkid",
+		Description: `This suite checks fork ID (EIP-2124) validation during the eth protocol handshake.
+Clients are started with several fork schedules and receive Status messages with crafted
+fork IDs. The test checks that the client accepts or rejects each peer as required by EIP-2124.`,
+	}
+	suite.Add(hivesim.TestSpec{
+		Name:        "fork ID matrix",
+		Description: "This test runs the fork ID checks for all eth1 clients and fork schedules.",
+		Run:         runMatrix,
+	})
+	hivesim.MustRunSuite(hivesim.New(), suite)
+}
+
+func runMatrix(t *hivesim.T) {
+	clients, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatal("can't get client types:", err)
+	}
+	for _, client := range clients {
+		if !client.HasRole("eth1") {
+			continue
+		}
+		for _, s := range schedules {
+			clientName, s := client.Name, s
+			t.Run(hivesim.TestSpec{
+				Name:        fmt.Sprintf("%s (%s)", s.name, clientName),
+				Description: fmt.Sprintf("Fork blocks %v, chain head at block %d.", s.forks, s.head),
+				Run:         func(t *hivesim.T) { runScheduleTest(t, clientName, &s) },
+			})
+		}
+	}
+}
+
+func runScheduleTest(t *hivesim.T, clientName string, s *schedule) {
+	chain, err := makeChain(s)
+	if err != nil {
+		t.Fatal("can't generate chain:", err)
+	}
+	client := t.StartClient(clientName, s.params(),
+		hivesim.WithDynamicFile("/genesis.json", bytesSource(chain.genesisJS)),
+		hivesim.WithDynamicFile("/chain.rlp", bytesSource(chain.chainRLP)),
+	)
+	enodeURL, err := client.EnodeURL()
+	if err != nil {
+		t.Fatal("can't get client enode URL:", err)
+	}
+	node, err := enode.ParseV4(enodeURL)
+	if err != nil {
+		t.Fatal("invalid client enode URL:", err)
+	}
+
+	// The client's own fork ID must match the schedule.
+	wantID := forkid.NewIDWithChain(chain)
+	filter := forkid.NewFilter(chain)
+	for _, tc := range forkIDCases(chain, s) {
+		wantAccept := filter(tc.id) == nil
+		result, err := statusHandshake(node, chain, tc.id, disconnectWait)
+		if err != nil {
+			t.Errorf("%s: handshake failed: %v", tc.name, err)
+			continue
+		}
+		if rs := result.remoteStatus; rs != nil && rs.ForkID != wantID {
+			t.Errorf("%s: client announced fork ID %v, want %v", tc.name, formatID(rs.ForkID), formatID(wantID))
+		}
+		switch {
+		case wantAccept && result.disconnected:
+			t.Errorf("%s: peer with fork ID %v was rejected (%s), but should be accepted", tc.name, formatID(tc.id), result.reason)
+		case !wantAccept && !result.disconnected:
+			t.Errorf("%s: peer with fork ID %v was accepted, but should be rejected", tc.name, formatID(tc.id))
+		default:
+			t.Logf("%s: fork ID %v, accepted: %t", tc.name, formatID(tc.id), wantAccept)
+		}
+	}
+}
+
+type forkIDCase struct {
+	name string
+	id   forkid.ID
+}
+
+// forkIDCases creates the fork IDs announced to the client. Cases which do not apply
+// to the schedule, e.g. remote peers on past forks when no fork has passed, are left out.
+func forkIDCases(chain *testChain, s *schedule) []forkIDCase {
+	var (
+		local  = forkid.NewIDWithChain(chain)
+		head   = uint64(s.head)
+		forks  = s.forkBlocks()
+		passed []uint64
+		future []uint64
+	)
+	for _, f := range forks {
+		if f <= head {
+			passed = append(passed, f)
+		} else {
+			future = append(future, f)
+		}
+	}
+	genesisSum := crc32.ChecksumIEEE(chain.Genesis().Hash().Bytes())
+	sum := func(forks []uint64) [4]byte {
+		hash := genesisSum
+		for _, f := range forks {
+			hash = checksumUpdate(hash, f)
+		}
+		return checksumToBytes(hash)
+	}
+
+	cases := []forkIDCase{
+		{"same fork ID", local},
+		{"same forks, no next fork", forkid.ID{Hash: local.Hash, Next: 0}},
+		{"same forks, unknown future fork", forkid.ID{Hash: local.Hash, Next: head + 1000}},
+		{"same forks, next fork already passed", forkid.ID{Hash: local.Hash, Next: head}},
+		{"unknown fork", forkid.ID{Hash: sum(append(append([]uint64{}, passed...), head+500)), Next: 0}},
+		{"different genesis", forkid.ID{Hash: checksumToBytes(crc32.ChecksumIEEE(make([]byte, 32))), Next: 0}},
+	}
+	if len(passed) > 0 {
+		// The remote is on an earlier fork and knows about the next one.
+		last := len(passed) - 1
+		cases = append(cases,
+			forkIDCase{"past fork, correct next fork", forkid.ID{Hash: sum(passed[:last]), Next: passed[last]}},
+			forkIDCase{"past fork, missing next fork", forkid.ID{Hash: sum(passed[:last]), Next: 0}},
+			forkIDCase{"past fork, wrong next fork", forkid.ID{Hash: sum(passed[:last]), Next: passed[last] + 1}},
+		)
+	}
+	if len(future) > 0 {
+		// The remote has passed a fork which is upcoming for the client.
+		var next uint64
+		if len(future) > 1 {
+			next = future[1]
+		}
+		cases = append(cases,
+			forkIDCase{"future fork", forkid.ID{Hash: sum(append(append([]uint64{}, passed...), future[0])), Next: next}},
+			forkIDCase{"future fork, unknown next fork", forkid.ID{Hash: sum(append(append([]uint64{}, passed...), future[0])), Next: future[0] + 1}},
+		)
+	}
+	return cases
+}
+
+func checksumUpdate(hash uint32, fork uint64) uint32 {
+	var blob [8]byte
+	binary.BigEndian.PutUint64(blob[:], fork)
+	return crc32.Update(hash, crc32.IEEETable, blob[:])
+}
+
+func checksumToBytes(hash uint32) [4]byte {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], hash)
+	return blob
+}
+
+func formatID(id forkid.ID) string {
+	return fmt.Sprintf("{hash: %x, next: %d}", id.Hash, id.Next)
+}
+
+func bytesSource(data []byte) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	}
+}
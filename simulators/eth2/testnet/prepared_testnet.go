@@ -92,6 +92,8 @@ func prepareTestnet(t *hivesim.T, valCount uint64, keyTranches uint64) *Prepared
 		"HIVE_ETH2_BN_GRPC_PORT": fmt.Sprintf("%d", PortBeaconGRPC),
 		"HIVE_ETH2_METRICS_PORT": fmt.Sprintf("%d", PortMetrics),
 		"HIVE_CHECK_LIVE_PORT":   fmt.Sprintf("%d", PortBeaconAPI),
+		// Make hive record the beacon node metrics.
+		"HIVE_METRICS_PORT": fmt.Sprintf("%d", PortMetrics),
 	}
 	validatorParams := hivesim.Params{
 		"HIVE_ETH2_BN_API_PORT":  fmt.Sprintf("%d", PortBeaconAPI),
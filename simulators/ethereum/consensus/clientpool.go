@@ -0,0 +1,283 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/hive/hivesim"
+)
+
+// resetScript is the optional client script which reinitializes a running client
+// with the genesis and blocks currently present in the container.
+const resetScript = "/reset.sh"
+
+// poolScripts are uploaded into pooled client containers. Exec requests can only run
+// scripts in /hive-bin.
+var poolScripts = map[string]string{
+	// hive-write-file <path> <base64 data> [append] writes a file.
+	"/hive-bin/hive-write-file": `#!/bin/sh
+set -e
+mkdir -p "$(dirname "$1")"
+if [ "$3" = "append" ]; then
+    printf %s "$2" | base64 -d >> "$1"
+else
+    printf %s "$2" | base64 -d > "$1"
+fi
+`,
+	// hive-reset [check|clean] runs the client's reset script. With argument 'check',
+	// it only checks for the script. With argument 'clean', it removes the test chain
+	// of the previous test.
+	"/hive-bin/hive-reset": `#!/bin/sh
+if [ ! -x ` + resetScript + ` ]; then
+    exit 127
+fi
+case "$1" in
+    check)
+        exit 0 ;;
+    clean)
+        rm -rf /blocks /chain.rlp
+        exit 0 ;;
+esac
+exec ` + resetScript + `
+`,
+}
+
+// fileChunkSize is the number of bytes written into a container by a single exec.
+const fileChunkSize = 48 * 1024
+
+// clientPool keeps client instances running across tests. Instances are started in
+// the context of the loader test, and reused for tests with the same client type
+// and environment by running the client's reset script.
+type clientPool struct {
+	t       *hivesim.T
+	backend poolBackend
+	dial    func(ip net.IP) (*rpc.Client, error)
+	maxIdle int
+
+	mu      sync.Mutex
+	idle    []*pooledClient
+	noReset map[string]bool // client types without reset script
+}
+
+type pooledClient struct {
+	key        string
+	clientType string
+	container  string
+	rpc        *rpc.Client
+}
+
+// poolBackend is the part of the simulation API used by the pool.
+type poolBackend interface {
+	StartClientWithOptions(suite hivesim.SuiteID, test hivesim.TestID, clientType string, options ...hivesim.StartOption) (string, net.IP, error)
+	StopClient(suite hivesim.SuiteID, test hivesim.TestID, container string) error
+	ClientExec(suite hivesim.SuiteID, test hivesim.TestID, container string, cmd []string) (*hivesim.ExecInfo, error)
+}
+
+func newClientPool(t *hivesim.T, maxIdle int) *clientPool {
+	return &clientPool{
+		t:       t,
+		backend: t.Sim,
+		dial:    dialClientRPC,
+		maxIdle: maxIdle,
+		noReset: make(map[string]bool),
+	}
+}
+
+// dialClientRPC connects to the RPC endpoint of a client container.
+func dialClientRPC(ip net.IP) (*rpc.Client, error) {
+	return rpc.DialHTTP(fmt.Sprintf("http://%v:8545", ip))
+}
+
+// get returns a client instance with the given environment and files. It returns nil
+// when the client type does not support reset, and the test should start a fresh
+// instance instead.
+func (p *clientPool) get(clientType string, env hivesim.Params, files map[string]string) (*pooledClient, error) {
+	key := poolKey(clientType, env)
+	p.mu.Lock()
+	if p.noReset[clientType] {
+		p.mu.Unlock()
+		return nil, nil
+	}
+	var c *pooledClient
+	for i, idle := range p.idle {
+		if idle.key == key {
+			c = idle
+			p.idle = append(p.idle[:i], p.idle[i+1:]...)
+			break
+		}
+	}
+	p.mu.Unlock()
+
+	if c != nil {
+		err := p.reset(c, files)
+		if err == nil {
+			return c, nil
+		}
+		p.t.Logf("reset of %s client %s failed: %v", c.clientType, c.container, err)
+		p.stop(c)
+	}
+	return p.start(key, clientType, env, files)
+}
+
+// put returns a client to the pool after a test has finished with it.
+func (p *clientPool) put(c *pooledClient) {
+	if !p.canReset(c) {
+		p.stop(c)
+		return
+	}
+
+	p.mu.Lock()
+	p.idle = append(p.idle, c)
+	var evict *pooledClient
+	if len(p.idle) > p.maxIdle {
+		evict = p.idle[0]
+		p.idle = p.idle[1:]
+	}
+	p.mu.Unlock()
+
+	if evict != nil {
+		p.stop(evict)
+	}
+}
+
+// start launches a new client instance. The instance belongs to the loader test.
+func (p *clientPool) start(key, clientType string, env hivesim.Params, files map[string]string) (*pooledClient, error) {
+	opts := []hivesim.StartOption{env, hivesim.WithStaticFiles(files)}
+	for target, script := range poolScripts {
+		script := script
+		opts = append(opts, hivesim.WithDynamicFile(target, func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(script)), nil
+		}))
+	}
+	container, ip, err := p.backend.StartClientWithOptions(p.t.SuiteID, p.t.TestID, clientType, opts...)
+	if err != nil {
+		return nil, err
+	}
+	rpcClient, _ := p.dial(ip)
+	return &pooledClient{key: key, clientType: clientType, container: container, rpc: rpcClient}, nil
+}
+
+func (p *clientPool) stop(c *pooledClient) {
+	c.rpc.Close()
+	if err := p.backend.StopClient(p.t.SuiteID, p.t.TestID, c.container); err != nil {
+		p.t.Logf("can't stop %s client %s: %v", c.clientType, c.container, err)
+	}
+}
+
+// canReset checks whether the client provides the reset script. The result is cached
+// per client type.
+func (p *clientPool) canReset(c *pooledClient) bool {
+	p.mu.Lock()
+	noReset := p.noReset[c.clientType]
+	p.mu.Unlock()
+	if noReset {
+		return false
+	}
+	info, err := p.exec(c, "hive-reset", "check")
+	if err != nil || info.ExitCode != 0 {
+		p.mu.Lock()
+		if !p.noReset[c.clientType] {
+			p.t.Logf("client %s has no %s, using fresh instances", c.clientType, resetScript)
+		}
+		p.noReset[c.clientType] = true
+		p.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// reset replaces the test files in the container and runs the reset script. It waits
+// until the client serves RPC again.
+func (p *clientPool) reset(c *pooledClient, files map[string]string) error {
+	// The previous chain must be removed before the new files are written, the reset
+	// script imports all blocks it finds.
+	if _, err := p.run(c, "hive-reset", "clean"); err != nil {
+		return err
+	}
+	targets := make([]string, 0, len(files))
+	for target := range files {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		if err := p.copyFile(c, files[target], target); err != nil {
+			return err
+		}
+	}
+	if _, err := p.run(c, "hive-reset"); err != nil {
+		return err
+	}
+	return waitRPC(c.rpc, 60*time.Second)
+}
+
+// copyFile writes a local file into the container. The content is sent in chunks
+// because command line arguments are limited in size.
+func (p *clientPool) copyFile(c *pooledClient, src, target string) error {
+	content, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	mode := ""
+	for start := 0; start == 0 || start < len(content); start += fileChunkSize {
+		end := start + fileChunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+		chunk := base64.StdEncoding.EncodeToString(content[start:end])
+		if _, err := p.run(c, "hive-write-file", target, chunk, mode); err != nil {
+			return fmt.Errorf("can't write %s: %v", target, err)
+		}
+		mode = "append"
+	}
+	return nil
+}
+
+// run executes a command in the container and fails if it returns a non-zero exit code.
+func (p *clientPool) run(c *pooledClient, cmd ...string) (*hivesim.ExecInfo, error) {
+	info, err := p.exec(c, cmd...)
+	if err != nil {
+		return nil, err
+	}
+	if info.ExitCode != 0 {
+		return info, fmt.Errorf("%s exited with code %d: %s", cmd[0], info.ExitCode, info.Stderr)
+	}
+	return info, nil
+}
+
+func (p *clientPool) exec(c *pooledClient, cmd ...string) (*hivesim.ExecInfo, error) {
+	return p.backend.ClientExec(p.t.SuiteID, p.t.TestID, c.container, cmd)
+}
+
+// waitRPC waits until the client responds to RPC requests.
+func waitRPC(client *rpc.Client, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var num string
+		err := client.Call(&num, "eth_blockNumber")
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("client RPC not available after reset: %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// poolKey identifies client instances which can be reused for a test.
+func poolKey(clientType string, env hivesim.Params) string {
+	keys := make([]string, 0, len(env))
+	for k, v := range env {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	return clientType + " " + strings.Join(keys, " ")
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/hive/hivesim"
+)
+
+// fakeContainer emulates a client container which supports the pool scripts. Running
+// the reset script imports all blocks in /blocks.
+type fakeContainer struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	head  uint64
+	rpc   *rpc.Server
+}
+
+func (c *fakeContainer) BlockNumber() hexutil.Uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return hexutil.Uint64(c.head)
+}
+
+func (c *fakeContainer) exec(cmd []string) (*hivesim.ExecInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case cmd[0] == "hive-write-file" && len(cmd) == 4:
+		data, err := base64.StdEncoding.DecodeString(cmd[2])
+		if err != nil {
+			return &hivesim.ExecInfo{ExitCode: 1, Stderr: err.Error()}, nil
+		}
+		if cmd[3] == "append" {
+			data = append(c.files[cmd[1]], data...)
+		}
+		c.files[cmd[1]] = data
+	case cmd[0] == "hive-reset" && len(cmd) == 2 && cmd[1] == "check":
+	case cmd[0] == "hive-reset" && len(cmd) == 2 && cmd[1] == "clean":
+		for name := range c.files {
+			if strings.HasPrefix(name, "/blocks/") || name == "/chain.rlp" {
+				delete(c.files, name)
+			}
+		}
+	case cmd[0] == "hive-reset" && len(cmd) == 1:
+		c.head = 0
+		for name := range c.files {
+			if strings.HasPrefix(name, "/blocks/") {
+				c.head++
+			}
+		}
+	default:
+		return &hivesim.ExecInfo{ExitCode: 127, Stderr: "unknown command"}, nil
+	}
+	return &hivesim.ExecInfo{}, nil
+}
+
+// fakePoolBackend implements poolBackend with fake containers.
+type fakePoolBackend struct {
+	mu         sync.Mutex
+	containers map[string]*fakeContainer
+}
+
+func (b *fakePoolBackend) StartClientWithOptions(suite hivesim.SuiteID, test hivesim.TestID, clientType string, options ...hivesim.StartOption) (string, net.IP, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := &fakeContainer{files: make(map[string][]byte), rpc: rpc.NewServer()}
+	if err := c.rpc.RegisterName("eth", c); err != nil {
+		return "", nil, err
+	}
+	id := fmt.Sprint(len(b.containers) + 1)
+	b.containers[id] = c
+	return id, net.IPv4(10, 0, 0, byte(len(b.containers))), nil
+}
+
+func (b *fakePoolBackend) StopClient(suite hivesim.SuiteID, test hivesim.TestID, container string) error {
+	return nil
+}
+
+func (b *fakePoolBackend) ClientExec(suite hivesim.SuiteID, test hivesim.TestID, container string, cmd []string) (*hivesim.ExecInfo, error) {
+	b.mu.Lock()
+	c := b.containers[container]
+	b.mu.Unlock()
+	return c.exec(cmd)
+}
+
+func (b *fakePoolBackend) dial(ip net.IP) (*rpc.Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.containers[fmt.Sprint(ip[len(ip)-1])]
+	return rpc.DialInProc(c.rpc), nil
+}
+
+// testFiles creates the files of a test with the given number of blocks.
+func testFiles(t *testing.T, blocks int) map[string]string {
+	dir := t.TempDir()
+	files := make(map[string]string)
+	write := func(target string) {
+		src := filepath.Join(dir, filepath.Base(target))
+		if err := ioutil.WriteFile(src, []byte(target), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files[target] = src
+	}
+	write("/genesis.json")
+	for i := 1; i <= blocks; i++ {
+		write(fmt.Sprintf("/blocks/%04d.rlp", i))
+	}
+	return files
+}
+
+func TestClientPoolReuse(t *testing.T) {
+	backend := &fakePoolBackend{containers: make(map[string]*fakeContainer)}
+	pool := newClientPool(&hivesim.T{}, 1)
+	pool.backend = backend
+	pool.dial = backend.dial
+	env := hivesim.Params{"HIVE_FORK_LONDON": "0"}
+
+	c, err := pool.get("client", env, testFiles(t, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.put(c)
+
+	// The reused client must import all blocks of the next test, and none of the
+	// blocks of the previous one.
+	for _, blocks := range []int{3, 2} {
+		reused, err := pool.get("client", env, testFiles(t, blocks))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reused != c {
+			t.Fatal("client was not reused")
+		}
+		var head hexutil.Uint64
+		if err := reused.rpc.Call(&head, "eth_blockNumber"); err != nil {
+			t.Fatal(err)
+		}
+		if uint64(head) != uint64(blocks) {
+			t.Errorf("head block of reused client is %d, want %d", head, blocks)
+		}
+		pool.put(reused)
+	}
+}
@@ -4,5 +4,5 @@ go 1.13
 
 require (
 	github.com/ethereum/go-ethereum v1.10.8
-	github.com/ethereum/hive v0.0.0-20201105181315-a9685e29a08a
+	github.com/ethereum/hive v0.0.0-20210901190357-cd81b24b2ac6
 )
@@ -265,13 +265,31 @@ func main() {
 		Description: "This is a meta-test. It loads the blockchain test files and " +
 			"launches the actual client tests. Any errors in test files will be reported " +
 			"through this test.",
-		Run: loaderTest,
+		Run: loaderTest("BlockchainTests", loadTests),
+	})
+	suite.Add(hivesim.TestSpec{
+		Name: "state test file loader",
+		Description: "This is a meta-test. It loads the state test files, converts them " +
+			"into blockchain tests with a single block and launches the client tests. " +
+			"Tests are named by fork.",
+		Run: loaderTest("GeneralStateTests", loadStateTests),
 	})
 	hivesim.MustRunSuite(hivesim.New(), suite)
 }
 
-// loaderTest loads the blockchain test files and spawns the client tests.
-func loaderTest(t *hivesim.T) {
+// loadFunc loads the tests in a directory, running the given function for each test.
+type loadFunc func(t *hivesim.T, root string, limit int, fn func(testcase))
+
+// loaderTest returns a test which loads the test files in the given subdirectory
+// of $TESTPATH and spawns the client tests.
+func loaderTest(dir string, load loadFunc) func(t *hivesim.T) {
+	return func(t *hivesim.T) {
+		runTests(t, dir, load)
+	}
+}
+
+// runTests loads the tests using the given loader and runs them against all clients.
+func runTests(t *hivesim.T, dir string, load loadFunc) {
 	clientTypes, err := t.Sim.ClientTypes()
 	if err != nil {
 		t.Fatal("can't get client types:", err)
@@ -293,14 +311,35 @@ func loaderTest(t *hivesim.T) {
 			testLimit = p
 		}
 	}
-	t.Log("parallelism:", parallelism, "testlimit:", testLimit)
+	checkPost := false
+	if val, ok := os.LookupEnv("HIVE_CHECK_POSTSTATE"); ok {
+		if p, err := strconv.ParseBool(val); err != nil {
+			t.Logf("Warning: invalid HIVE_CHECK_POSTSTATE value %q", val)
+		} else {
+			checkPost = p
+		}
+	}
+	reuseClients := false
+	if val, ok := os.LookupEnv("HIVE_REUSE_CLIENTS"); ok {
+		if p, err := strconv.ParseBool(val); err != nil {
+			t.Logf("Warning: invalid HIVE_REUSE_CLIENTS value %q", val)
+		} else {
+			reuseClients = p
+		}
+	}
+	t.Log("parallelism:", parallelism, "testlimit:", testLimit, "checkpost:", checkPost, "reuse:", reuseClients)
+
+	var pool *clientPool
+	if reuseClients {
+		pool = newClientPool(t, parallelism)
+	}
 
 	// Find the tests directory.
 	testPath, isset := os.LookupEnv("TESTPATH")
 	if !isset {
 		t.Fatal("$TESTPATH not set")
 	}
-	fileRoot := fmt.Sprintf("%s/BlockchainTests/", testPath)
+	fileRoot := fmt.Sprintf("%s/%s/", testPath, dir)
 
 	// Spawn workers.
 	var wg sync.WaitGroup
@@ -320,10 +359,12 @@ func loaderTest(t *hivesim.T) {
 	}
 
 	// Deliver test cases.
-	loadTests(t, fileRoot, testLimit, func(tc testcase) {
+	load(t, fileRoot, testLimit, func(tc testcase) {
 		for _, client := range clientTypes {
 			tc := tc // shallow copy
-			tc.clientType = client
+			tc.clientType = client.Name
+			tc.checkPost = checkPost
+			tc.pool = pool
 			testCh <- &tc
 		}
 	})
@@ -383,6 +424,8 @@ type testcase struct {
 	clientType string
 	blockTest  BlockTest
 	filepath   string
+	checkPost  bool
+	pool       *clientPool // non-nil if client instances are reused
 }
 
 // validate returns error if the test's chain rules are not supported.
@@ -403,7 +446,7 @@ func (tc *testcase) run(t *hivesim.T) {
 	}
 
 	// update the parameters with test-specific stuff
-	env := map[string]string{
+	env := hivesim.Params{
 		"HIVE_FORK_DAO_VOTE": "1",
 		"HIVE_CHAIN_ID":      "1",
 	}
@@ -418,10 +461,11 @@ func (tc *testcase) run(t *hivesim.T) {
 	}
 
 	t1 := time.Now()
-	client := t.StartClient(tc.clientType, env, files)
+	client, release := tc.startClient(t, env, files)
+	defer release()
 
 	t2 := time.Now()
-	genesisHash, genesisResponse, err := getBlock(client.RPC(), "0x0")
+	genesisHash, genesisResponse, err := getBlock(client, "0x0")
 	if err != nil {
 		t.Fatalf("can't get genesis: %v", err)
 	}
@@ -436,7 +480,7 @@ func (tc *testcase) run(t *hivesim.T) {
 
 	// verify postconditions
 	t3 := time.Now()
-	lastHash, lastResponse, err := getBlock(client.RPC(), "latest")
+	lastHash, lastResponse, err := getBlock(client, "latest")
 	if err != nil {
 		t.Fatal("can't get latest block:", err)
 	}
@@ -448,11 +492,41 @@ func (tc *testcase) run(t *hivesim.T) {
 	}
 
 	t4 := time.Now()
+	if tc.checkPost {
+		post, err := tc.blockTest.postState()
+		if err != nil {
+			t.Fatal("invalid postState:", err)
+		}
+		for _, err := range verifyPostState(client, post) {
+			t.Error(err)
+		}
+	}
+
+	t5 := time.Now()
 	t.Logf(`test timing:
   artefacts    %v
   startClient  %v
   checkGenesis %v
-  checkLatest  %v`, t1.Sub(start), t2.Sub(t1), t3.Sub(t2), t4.Sub(t3))
+  checkLatest  %v
+  checkPost    %v`, t1.Sub(start), t2.Sub(t1), t3.Sub(t2), t4.Sub(t3), t5.Sub(t4))
+}
+
+// startClient launches the client with the given environment and files, and returns
+// its RPC client. When client instances are reused, the instance is taken from the
+// pool, and the returned release function must be called when the test ends.
+func (tc *testcase) startClient(t *hivesim.T, env hivesim.Params, files map[string]string) (*rpc.Client, func()) {
+	if tc.pool != nil {
+		c, err := tc.pool.get(tc.clientType, env, files)
+		if err != nil {
+			t.Fatalf("can't launch node (type %s): %v", tc.clientType, err)
+		}
+		if c != nil {
+			t.Logf("using client instance %s", c.container)
+			return c.rpc, func() { tc.pool.put(c) }
+		}
+	}
+	client := t.StartClient(tc.clientType, env, hivesim.WithStaticFiles(files))
+	return client.RPC(), func() {}
 }
 
 // updateEnv sets environment variables from the test
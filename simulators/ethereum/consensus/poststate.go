@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// verifyPostState checks the balance, nonce, code and storage of all accounts in the
+// expected post-state against the latest state of the client. It returns one error per
+// mismatching account.
+func verifyPostState(client *rpc.Client, post core.GenesisAlloc) []error {
+	var errs []error
+	for addr, account := range post {
+		if err := verifyAccount(client, addr, account); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// verifyAccount checks a single account. All values are fetched in one batch request.
+func verifyAccount(client *rpc.Client, addr common.Address, want core.GenesisAccount) error {
+	var (
+		balance hexutil.Big
+		nonce   hexutil.Uint64
+		code    hexutil.Bytes
+		keys    []common.Hash
+		storage []hexutil.Bytes
+	)
+	batch := []rpc.BatchElem{
+		{Method: "eth_getBalance", Args: []interface{}{addr, "latest"}, Result: &balance},
+		{Method: "eth_getTransactionCount", Args: []interface{}{addr, "latest"}, Result: &nonce},
+		{Method: "eth_getCode", Args: []interface{}{addr, "latest"}, Result: &code},
+	}
+	for key := range want.Storage {
+		keys = append(keys, key)
+	}
+	storage = make([]hexutil.Bytes, len(keys))
+	for i, key := range keys {
+		batch = append(batch, rpc.BatchElem{
+			Method: "eth_getStorageAt",
+			Args:   []interface{}{addr, key, "latest"},
+			Result: &storage[i],
+		})
+	}
+	if err := client.BatchCall(batch); err != nil {
+		return fmt.Errorf("account %x: %v", addr, err)
+	}
+	for _, elem := range batch {
+		if elem.Error != nil {
+			return fmt.Errorf("account %x: %s failed: %v", addr, elem.Method, elem.Error)
+		}
+	}
+
+	// Compare the results.
+	var diffs []string
+	wantBalance := want.Balance
+	if wantBalance == nil {
+		wantBalance = new(big.Int)
+	}
+	if (*big.Int)(&balance).Cmp(wantBalance) != 0 {
+		diffs = append(diffs, fmt.Sprintf("balance: want %v, got %v", wantBalance, (*big.Int)(&balance)))
+	}
+	if uint64(nonce) != want.Nonce {
+		diffs = append(diffs, fmt.Sprintf("nonce: want %d, got %d", want.Nonce, uint64(nonce)))
+	}
+	if !bytes.Equal(code, want.Code) {
+		diffs = append(diffs, fmt.Sprintf("code: want 0x%x, got 0x%x", want.Code, []byte(code)))
+	}
+	for i, key := range keys {
+		if got := common.BytesToHash(storage[i]); got != want.Storage[key] {
+			diffs = append(diffs, fmt.Sprintf("storage %x: want %x, got %x", key, want.Storage[key], got))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("account %x mismatch:\n  %s", addr, strings.Join(diffs, "\n  "))
+	}
+	return nil
+}
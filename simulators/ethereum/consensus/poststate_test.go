@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeEth serves account state from an alloc.
+type fakeEth struct {
+	alloc core.GenesisAlloc
+}
+
+func (f *fakeEth) GetBalance(ctx context.Context, addr common.Address, block string) *hexutil.Big {
+	if b := f.alloc[addr].Balance; b != nil {
+		return (*hexutil.Big)(b)
+	}
+	return new(hexutil.Big)
+}
+
+func (f *fakeEth) GetTransactionCount(ctx context.Context, addr common.Address, block string) hexutil.Uint64 {
+	return hexutil.Uint64(f.alloc[addr].Nonce)
+}
+
+func (f *fakeEth) GetCode(ctx context.Context, addr common.Address, block string) hexutil.Bytes {
+	return f.alloc[addr].Code
+}
+
+func (f *fakeEth) GetStorageAt(ctx context.Context, addr common.Address, key common.Hash, block string) hexutil.Bytes {
+	v := f.alloc[addr].Storage[key]
+	return v[:]
+}
+
+func TestVerifyPostState(t *testing.T) {
+	var (
+		addr1 = common.HexToAddress("0x095e7baea6a6c7c4c2dfeb977efac326af552d87")
+		addr2 = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+		key   = common.HexToHash("0x01")
+	)
+	state := core.GenesisAlloc{
+		addr1: {
+			Balance: big.NewInt(100),
+			Code:    []byte{0x60, 0x00},
+			Storage: map[common.Hash]common.Hash{key: common.HexToHash("0x02")},
+		},
+		addr2: {Balance: big.NewInt(5), Nonce: 1},
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &fakeEth{state}); err != nil {
+		t.Fatal(err)
+	}
+	client := rpc.DialInProc(server)
+	defer client.Close()
+
+	if errs := verifyPostState(client, state); len(errs) != 0 {
+		t.Fatalf("unexpected errors for matching state: %v", errs)
+	}
+
+	post := core.GenesisAlloc{
+		addr1: {
+			Balance: big.NewInt(100),
+			Code:    []byte{0x60, 0x00},
+			Storage: map[common.Hash]common.Hash{key: common.HexToHash("0x03")},
+		},
+		addr2: {Balance: big.NewInt(6), Nonce: 1},
+	}
+	errs := verifyPostState(client, post)
+	if len(errs) != 2 {
+		t.Fatalf("wrong number of errors: %v", errs)
+	}
+	for _, err := range errs {
+		switch {
+		case strings.Contains(err.Error(), "095e7baea6a6c7c4c2dfeb977efac326af552d87"):
+			if !strings.Contains(err.Error(), "storage") || strings.Contains(err.Error(), "balance") {
+				t.Errorf("wrong error for account 1: %v", err)
+			}
+		case strings.Contains(err.Error(), "a94f5374fce5edbc8e2a8697c15331677e6ebf0b"):
+			if !strings.Contains(err.Error(), "balance: want 6, got 5") {
+				t.Errorf("wrong error for account 2: %v", err)
+			}
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestBlockTestPostState(t *testing.T) {
+	var bt BlockTest
+	input := `{"postState": {"0x095e7baea6a6c7c4c2dfeb977efac326af552d87": {"balance": "0x64", "nonce": "0x01", "code": "0x", "storage": {}}}}`
+	if err := json.Unmarshal([]byte(input), &bt); err != nil {
+		t.Fatal(err)
+	}
+	post, err := bt.postState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, ok := post[common.HexToAddress("0x095e7baea6a6c7c4c2dfeb977efac326af552d87")]
+	if !ok || account.Balance.Int64() != 100 || account.Nonce != 1 {
+		t.Fatalf("wrong post state: %v", post)
+	}
+
+	// Tests without postState have no post-state.
+	var noPost BlockTest
+	if err := json.Unmarshal([]byte(`{"postStateHash": "0x00"}`), &noPost); err != nil {
+		t.Fatal(err)
+	}
+	if post, err := noPost.postState(); post != nil || err != nil {
+		t.Fatalf("expected no post state, got %v, %v", post, err)
+	}
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/hive/hivesim"
+)
+
+// A StateTest checks transaction processing without any surrounding blocks. State tests
+// are run by converting them into blockchain tests with a single block.
+type StateTest struct {
+	json stJSON
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+func (t *StateTest) UnmarshalJSON(in []byte) error {
+	return json.Unmarshal(in, &t.json)
+}
+
+type stJSON struct {
+	Env  stEnv                    `json:"env"`
+	Pre  core.GenesisAlloc        `json:"pre"`
+	Tx   stTransaction            `json:"transaction"`
+	Post map[string][]stPostState `json:"post"`
+}
+
+type stEnv struct {
+	Coinbase   common.Address        `json:"currentCoinbase"`
+	Difficulty *math.HexOrDecimal256 `json:"currentDifficulty"`
+	GasLimit   math.HexOrDecimal64   `json:"currentGasLimit"`
+	Number     math.HexOrDecimal64   `json:"currentNumber"`
+	Timestamp  math.HexOrDecimal64   `json:"currentTimestamp"`
+	BaseFee    *math.HexOrDecimal256 `json:"currentBaseFee"`
+}
+
+type stTransaction struct {
+	GasPrice             *math.HexOrDecimal256 `json:"gasPrice"`
+	MaxFeePerGas         *math.HexOrDecimal256 `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *math.HexOrDecimal256 `json:"maxPriorityFeePerGas"`
+	Nonce                math.HexOrDecimal64   `json:"nonce"`
+	To                   string                `json:"to"`
+	Data                 []string              `json:"data"`
+	AccessLists          []*types.AccessList   `json:"accessLists"`
+	GasLimit             []math.HexOrDecimal64 `json:"gasLimit"`
+	Value                []string              `json:"value"`
+	PrivateKey           hexutil.Bytes         `json:"secretKey"`
+}
+
+type stPostState struct {
+	Root            common.UnprefixedHash `json:"hash"`
+	TxBytes         hexutil.Bytes         `json:"txbytes"`
+	ExpectException string                `json:"expectException"`
+	Indexes         struct {
+		Data  int `json:"data"`
+		Gas   int `json:"gas"`
+		Value int `json:"value"`
+	} `json:"indexes"`
+}
+
+// subtestName returns the name of the blockchain test created from a post state.
+func subtestName(name, fork string, post stPostState) string {
+	return fmt.Sprintf("%s: %s_d%dg%dv%d", fork, name, post.Indexes.Data, post.Indexes.Gas, post.Indexes.Value)
+}
+
+// loadStateTests loads the state test files in 'root', running the given function for
+// each fork and post state of every test.
+func loadStateTests(t *hivesim.T, root string, limit int, fn func(testcase)) {
+	var i, failed = 0, 0
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if limit >= 0 && i >= limit {
+			return filepath.SkipDir
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if fname := info.Name(); !strings.HasSuffix(fname, ".json") {
+			return nil
+		}
+		var tests map[string]StateTest
+		if err := common.LoadJSON(path, &tests); err != nil {
+			t.Logf("invalid test file: %v", err)
+			return nil
+		}
+
+		for name, statetest := range tests {
+			for _, fork := range statetest.forks() {
+				for _, post := range statetest.json.Post[fork] {
+					tc := testcase{name: subtestName(name, fork, post), filepath: path}
+					bt, err := statetest.blockTest(fork, post)
+					if err != nil {
+						t.Errorf("can't convert %s: %v", tc.name, err)
+						failed++
+						continue
+					}
+					tc.blockTest = *bt
+					fn(tc)
+					i++
+				}
+			}
+		}
+		return nil
+	})
+	t.Logf("converted %d state tests, %d failed", i, failed)
+}
+
+// forks returns the sorted forks of the test which are defined in the ruleset. Post
+// states of other forks are ignored.
+func (t *StateTest) forks() []string {
+	var forks []string
+	for fork := range t.json.Post {
+		if _, ok := ruleset[fork]; ok {
+			forks = append(forks, fork)
+		}
+	}
+	sort.Strings(forks)
+	return forks
+}
+
+// blockTest converts a post state of the test into a blockchain test. The block
+// containing the transaction is built by go-ethereum, and the resulting state root is
+// checked against the post state.
+func (t *StateTest) blockTest(fork string, post stPostState) (*BlockTest, error) {
+	config := rulesetConfig(ruleset[fork])
+	if uint64(t.json.Env.Number) != 1 {
+		return nil, fmt.Errorf("block number %d not supported", t.json.Env.Number)
+	}
+
+	// Create the genesis block from the pre-state. Its difficulty and timestamp are
+	// chosen such that block 1 has the difficulty of the test environment.
+	if t.json.Env.Difficulty == nil {
+		return nil, fmt.Errorf("missing currentDifficulty")
+	}
+	if t.json.Env.Timestamp < genesisTimeOffset {
+		return nil, fmt.Errorf("timestamp %d not supported", t.json.Env.Timestamp)
+	}
+	var (
+		difficulty  = (*big.Int)(t.json.Env.Difficulty)
+		timestamp   = uint64(t.json.Env.Timestamp)
+		genesisTime = timestamp - genesisTimeOffset
+	)
+	genesisDiff, err := genesisDifficulty(config, difficulty, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	gspec := &core.Genesis{
+		Config:     config,
+		Coinbase:   t.json.Env.Coinbase,
+		Difficulty: genesisDiff,
+		Timestamp:  genesisTime,
+		GasLimit:   uint64(t.json.Env.GasLimit),
+		Alloc:      t.json.Pre,
+	}
+	if config.IsLondon(common.Big0) && t.json.Env.BaseFee != nil {
+		gspec.BaseFee = genesisBaseFee((*big.Int)(t.json.Env.BaseFee))
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := gspec.MustCommit(db)
+
+	// The post-state is read by dumping the state trie, which needs the preimages of
+	// all account and storage trie keys.
+	rawdb.WritePreimages(db, allocPreimages(t.json.Pre))
+	statedb, err := state.New(genesis.Root(), state.NewDatabaseWithConfig(db, &trie.Config{Preimages: true}), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create block 1 and apply the transaction.
+	tx, err := t.transaction(config, post)
+	if err != nil {
+		return nil, err
+	}
+	header := &types.Header{
+		ParentHash: genesis.Hash(),
+		Coinbase:   t.json.Env.Coinbase,
+		Number:     big.NewInt(1),
+		GasLimit:   genesis.GasLimit(),
+		Time:       timestamp,
+		Difficulty: difficulty,
+	}
+	if config.IsLondon(header.Number) {
+		header.BaseFee = misc.CalcBaseFee(config, genesis.Header())
+	}
+	var (
+		chain   = &stChain{config, genesis.Header()}
+		gaspool = new(core.GasPool).AddGas(header.GasLimit)
+	)
+	statedb.Prepare(tx.Hash(), 0)
+	receipt, err := core.ApplyTransaction(config, chain, &header.Coinbase, gaspool, statedb, header, tx, &header.GasUsed, vm.Config{})
+
+	// Invalid transactions make the block invalid, and the client must stay at genesis.
+	var (
+		block, best *types.Block
+		postState   = t.json.Pre
+	)
+	if err != nil {
+		if post.ExpectException == "" {
+			return nil, fmt.Errorf("transaction is invalid: %v", err)
+		}
+		header.Root = genesis.Root()
+		header.GasUsed = 0
+		block = types.NewBlock(header, []*types.Transaction{tx}, nil, nil, trie.NewStackTrie(nil))
+		best = genesis
+	} else {
+		if post.ExpectException != "" {
+			return nil, fmt.Errorf("transaction is valid, but test expects exception %s", post.ExpectException)
+		}
+		// The state test runner touches the coinbase even if no fee is paid.
+		statedb.AddBalance(header.Coinbase, new(big.Int))
+		if root := statedb.IntermediateRoot(config.IsEIP158(header.Number)); root != common.Hash(post.Root) {
+			return nil, fmt.Errorf("post state root mismatch: got %x, want %x", root, post.Root)
+		}
+		txs, receipts := []*types.Transaction{tx}, []*types.Receipt{receipt}
+		block, err = chain.Engine().FinalizeAndAssemble(chain, header, statedb, txs, nil, receipts)
+		if err != nil {
+			return nil, err
+		}
+		best = block
+		if _, err := statedb.Commit(config.IsEIP158(header.Number)); err != nil {
+			return nil, err
+		}
+		postState = dumpAlloc(statedb)
+	}
+
+	blockRLP, _ := rlp.EncodeToBytes(block)
+	blockHeader := newBTHeader(block.Header())
+	return &BlockTest{post: postState, json: btJSON{
+		Blocks:     []btBlock{{BlockHeader: &blockHeader, Rlp: hexutil.Encode(blockRLP)}},
+		Genesis:    newBTHeader(genesis.Header()),
+		Pre:        t.json.Pre,
+		BestBlock:  common.UnprefixedHash(best.Hash()),
+		Network:    fork,
+		SealEngine: "NoProof",
+	}}, nil
+}
+
+// transaction returns the signed transaction of a post state.
+func (t *StateTest) transaction(config *params.ChainConfig, post stPostState) (*types.Transaction, error) {
+	if len(post.TxBytes) > 0 {
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(post.TxBytes); err != nil {
+			return nil, fmt.Errorf("invalid txbytes: %v", err)
+		}
+		return tx, nil
+	}
+
+	stx := &t.json.Tx
+	if post.Indexes.Data >= len(stx.Data) || post.Indexes.Gas >= len(stx.GasLimit) || post.Indexes.Value >= len(stx.Value) {
+		return nil, fmt.Errorf("transaction index out of range")
+	}
+	key, err := crypto.ToECDSA(stx.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	value, ok := math.ParseBig256(stx.Value[post.Indexes.Value])
+	if !ok {
+		return nil, fmt.Errorf("invalid tx value %q", stx.Value[post.Indexes.Value])
+	}
+	var to *common.Address
+	if stx.To != "" {
+		addr := common.HexToAddress(stx.To)
+		to = &addr
+	}
+	var (
+		nonce = uint64(stx.Nonce)
+		gas   = uint64(stx.GasLimit[post.Indexes.Gas])
+		data  = common.FromHex(stx.Data[post.Indexes.Data])
+	)
+	var accessList types.AccessList
+	if post.Indexes.Data < len(stx.AccessLists) && stx.AccessLists[post.Indexes.Data] != nil {
+		accessList = *stx.AccessLists[post.Indexes.Data]
+	}
+
+	var txdata types.TxData
+	switch {
+	case stx.MaxFeePerGas != nil:
+		txdata = &types.DynamicFeeTx{
+			ChainID:    config.ChainID,
+			Nonce:      nonce,
+			GasTipCap:  (*big.Int)(stx.MaxPriorityFeePerGas),
+			GasFeeCap:  (*big.Int)(stx.MaxFeePerGas),
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}
+	case accessList != nil:
+		txdata = &types.AccessListTx{
+			ChainID:    config.ChainID,
+			Nonce:      nonce,
+			GasPrice:   (*big.Int)(stx.GasPrice),
+			Gas:        gas,
+			To:         to,
+			Value:      value,
+			Data:       data,
+			AccessList: accessList,
+		}
+	default:
+		txdata = &types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: (*big.Int)(stx.GasPrice),
+			Gas:      gas,
+			To:       to,
+			Value:    value,
+			Data:     data,
+		}
+	}
+	return types.SignNewTx(key, stSigner(config, txdata), txdata)
+}
+
+// stSigner returns the signer for state test transactions. Like in the test fillers,
+// legacy transactions are not replay-protected.
+func stSigner(config *params.ChainConfig, txdata types.TxData) types.Signer {
+	if _, ok := txdata.(*types.LegacyTx); ok {
+		return types.HomesteadSigner{}
+	}
+	return types.LatestSigner(config)
+}
+
+// rulesetConfig creates the chain configuration corresponding to a ruleset.
+func rulesetConfig(rules envvars) *params.ChainConfig {
+	block := func(key string) *big.Int {
+		if v, ok := rules[key]; ok {
+			return big.NewInt(int64(v))
+		}
+		return nil
+	}
+	return &params.ChainConfig{
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      block("HIVE_FORK_HOMESTEAD"),
+		DAOForkBlock:        block("HIVE_FORK_DAO_BLOCK"),
+		DAOForkSupport:      block("HIVE_FORK_DAO_BLOCK") != nil,
+		EIP150Block:         block("HIVE_FORK_TANGERINE"),
+		EIP155Block:         block("HIVE_FORK_SPURIOUS"),
+		EIP158Block:         block("HIVE_FORK_SPURIOUS"),
+		ByzantiumBlock:      block("HIVE_FORK_BYZANTIUM"),
+		ConstantinopleBlock: block("HIVE_FORK_CONSTANTINOPLE"),
+		PetersburgBlock:     block("HIVE_FORK_PETERSBURG"),
+		IstanbulBlock:       block("HIVE_FORK_ISTANBUL"),
+		BerlinBlock:         block("HIVE_FORK_BERLIN"),
+		LondonBlock:         block("HIVE_FORK_LONDON"),
+		Ethash:              new(params.EthashConfig),
+	}
+}
+
+// genesisTimeOffset is the time between the genesis block and block 1 of converted
+// state tests. With this offset, the difficulty of block 1 equals the genesis
+// difficulty since Homestead.
+const genesisTimeOffset = 10
+
+// genesisDifficulty returns the difficulty of a genesis block such that block 1 at the
+// given timestamp has the given difficulty.
+func genesisDifficulty(config *params.ChainConfig, want *big.Int, timestamp uint64) (*big.Int, error) {
+	calc := func(diff *big.Int) *big.Int {
+		parent := &types.Header{
+			Number:     new(big.Int),
+			Time:       timestamp - genesisTimeOffset,
+			Difficulty: diff,
+			UncleHash:  types.EmptyUncleHash,
+		}
+		return ethash.CalcDifficulty(config, timestamp, parent)
+	}
+	// The child difficulty grows with the parent difficulty, so the genesis difficulty
+	// can be found by binary search.
+	lo, hi := big.NewInt(1), new(big.Int).Lsh(want, 1)
+	for lo.Cmp(hi) < 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if calc(mid).Cmp(want) < 0 {
+			lo = mid.Add(mid, common.Big1)
+		} else {
+			hi = mid
+		}
+	}
+	if calc(lo).Cmp(want) != 0 {
+		return nil, fmt.Errorf("difficulty %v can't be reached from genesis", want)
+	}
+	return lo, nil
+}
+
+// allocPreimages returns the preimages of the trie keys of an alloc.
+func allocPreimages(alloc core.GenesisAlloc) map[common.Hash][]byte {
+	preimages := make(map[common.Hash][]byte)
+	for addr, account := range alloc {
+		preimages[crypto.Keccak256Hash(addr[:])] = common.CopyBytes(addr[:])
+		for key := range account.Storage {
+			preimages[crypto.Keccak256Hash(key[:])] = common.CopyBytes(key[:])
+		}
+	}
+	return preimages
+}
+
+// dumpAlloc returns all accounts of a committed state.
+func dumpAlloc(statedb *state.StateDB) core.GenesisAlloc {
+	alloc := make(core.GenesisAlloc)
+	for addr, account := range statedb.RawDump(nil).Accounts {
+		balance, _ := new(big.Int).SetString(account.Balance, 10)
+		storage := make(map[common.Hash]common.Hash, len(account.Storage))
+		for key, value := range account.Storage {
+			storage[key] = common.HexToHash(value)
+		}
+		alloc[addr] = core.GenesisAccount{
+			Balance: balance,
+			Nonce:   account.Nonce,
+			Code:    account.Code,
+			Storage: storage,
+		}
+	}
+	return alloc
+}
+
+// genesisBaseFee returns the base fee of a genesis block such that the base fee of
+// the empty genesis block's child is baseFee.
+func genesisBaseFee(baseFee *big.Int) *big.Int {
+	// An empty parent block decreases the base fee by 1/8.
+	fee := new(big.Int).Mul(baseFee, big.NewInt(8))
+	fee.Div(fee, big.NewInt(7))
+	for {
+		child := new(big.Int).Sub(fee, new(big.Int).Div(fee, big.NewInt(params.BaseFeeChangeDenominator)))
+		switch child.Cmp(baseFee) {
+		case 0:
+			return fee
+		case -1:
+			fee.Add(fee, common.Big1)
+		default:
+			fee.Sub(fee, common.Big1)
+		}
+	}
+}
+
+// newBTHeader converts a block header to the blockchain test format.
+func newBTHeader(h *types.Header) btHeader {
+	return btHeader{
+		Bloom:            h.Bloom,
+		Coinbase:         h.Coinbase,
+		MixHash:          h.MixDigest,
+		Nonce:            h.Nonce,
+		Number:           h.Number,
+		Hash:             h.Hash(),
+		ParentHash:       h.ParentHash,
+		ReceiptTrie:      h.ReceiptHash,
+		StateRoot:        h.Root,
+		TransactionsTrie: h.TxHash,
+		UncleHash:        h.UncleHash,
+		ExtraData:        h.Extra,
+		Difficulty:       h.Difficulty,
+		GasLimit:         h.GasLimit,
+		GasUsed:          h.GasUsed,
+		Timestamp:        new(big.Int).SetUint64(h.Time),
+		BaseFee:          h.BaseFee,
+	}
+}
+
+// stChain is the chain used when building state test blocks. It contains only
+// the genesis block.
+type stChain struct {
+	config  *params.ChainConfig
+	genesis *types.Header
+}
+
+func (c *stChain) Config() *params.ChainConfig  { return c.config }
+func (c *stChain) Engine() consensus.Engine     { return ethash.NewFaker() }
+func (c *stChain) CurrentHeader() *types.Header { return c.genesis }
+
+func (c *stChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if hash == c.genesis.Hash() && number == 0 {
+		return c.genesis
+	}
+	return nil
+}
+
+func (c *stChain) GetHeaderByNumber(number uint64) *types.Header {
+	if number == 0 {
+		return c.genesis
+	}
+	return nil
+}
+
+func (c *stChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	if hash == c.genesis.Hash() {
+		return c.genesis
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestGenesisBaseFee(t *testing.T) {
+	config := rulesetConfig(ruleset["London"])
+	for _, want := range []int64{1, 7, 10, 1000000000, 875000001} {
+		genesis := &types.Header{
+			Number:   new(big.Int),
+			GasLimit: 30000000,
+			BaseFee:  genesisBaseFee(big.NewInt(want)),
+		}
+		if got := misc.CalcBaseFee(config, genesis); got.Int64() != want {
+			t.Errorf("wrong child base fee for %d: got %d", want, got)
+		}
+	}
+}
+
+// conversionTest calls a contract which stores DIFFICULTY and TIMESTAMP. The post
+// state roots were computed by the go-ethereum state test runner.
+const conversionTest = `{
+  "conversion": {
+    "env": {
+      "currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+      "currentDifficulty": "0x020000",
+      "currentGasLimit": "0x05f5e100",
+      "currentNumber": "0x01",
+      "currentTimestamp": "0x03e8",
+      "currentBaseFee": "0x0a"
+    },
+    "pre": {
+      "0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {
+        "balance": "0x0de0b6b3a7640000",
+        "code": "0x",
+        "nonce": "0x00",
+        "storage": {}
+      },
+      "0x1000000000000000000000000000000000000000": {
+        "balance": "0x00",
+        "code": "0x4460005542600155",
+        "nonce": "0x00",
+        "storage": {}
+      }
+    },
+    "transaction": {
+      "data": ["0x", "0x"],
+      "accessLists": [
+        null,
+        [{"address": "0x1000000000000000000000000000000000000000", "storageKeys": ["0x0000000000000000000000000000000000000000000000000000000000000000"]}]
+      ],
+      "gasLimit": ["0x0186a0", "0x03e8"],
+      "gasPrice": "0x0a",
+      "nonce": "0x00",
+      "secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+      "to": "0x1000000000000000000000000000000000000000",
+      "value": ["0x01"]
+    },
+    "post": {
+      "London": [
+        {"hash": "0x1014f6a54b2b2471f25d44364751c4876c59af3c99b7859d189ad2b26b577ec3", "indexes": {"data": 0, "gas": 0, "value": 0}, "logs": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347"},
+        {"hash": "0x1c697fe3d55783f0bfb20f2502afb1c5a5baf00ea0257d55ee93698941fe9e66", "indexes": {"data": 1, "gas": 0, "value": 0}, "logs": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347"},
+        {"hash": "0xacafeae9e82da3cfbcc979954a5d477056302c69ba508c74cb5d3efd00562464", "indexes": {"data": 0, "gas": 1, "value": 0}, "logs": "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", "expectException": "TR_IntrinsicGas"}
+      ]
+    }
+  }
+}`
+
+func TestStateTestConversion(t *testing.T) {
+	var tests map[string]StateTest
+	if err := json.Unmarshal([]byte(conversionTest), &tests); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		st       = tests["conversion"]
+		contract = common.HexToAddress("0x1000000000000000000000000000000000000000")
+		sender   = common.HexToAddress("0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+	)
+	wantTypes := []byte{types.LegacyTxType, types.AccessListTxType, types.LegacyTxType}
+	for i, post := range st.json.Post["London"] {
+		name := subtestName("conversion", "London", post)
+		bt, err := st.blockTest("London", post)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var block types.Block
+		if err := rlp.DecodeBytes(common.FromHex(bt.json.Blocks[0].Rlp), &block); err != nil {
+			t.Fatalf("%s: invalid block RLP: %v", name, err)
+		}
+		if block.Difficulty().Cmp(big.NewInt(0x20000)) != 0 {
+			t.Errorf("%s: block difficulty %v, want %v", name, block.Difficulty(), 0x20000)
+		}
+		if tx := block.Transactions()[0]; tx.Type() != wantTypes[i] {
+			t.Errorf("%s: tx type %d, want %d", name, tx.Type(), wantTypes[i])
+		}
+
+		if post.ExpectException != "" {
+			// The block is invalid, so the client stays at genesis.
+			if common.Hash(bt.json.BestBlock) != bt.json.Genesis.Hash {
+				t.Errorf("%s: best block is not genesis", name)
+			}
+			if bt.post[sender].Nonce != 0 {
+				t.Errorf("%s: sender nonce changed in post state", name)
+			}
+			continue
+		}
+		if common.Hash(bt.json.BestBlock) != block.Hash() {
+			t.Errorf("%s: best block is not block 1", name)
+		}
+		if bt.post[sender].Nonce != 1 {
+			t.Errorf("%s: sender nonce %d in post state, want 1", name, bt.post[sender].Nonce)
+		}
+		storage := bt.post[contract].Storage
+		if v := storage[common.Hash{}]; v != common.BigToHash(big.NewInt(0x20000)) {
+			t.Errorf("%s: stored DIFFICULTY is %x", name, v)
+		}
+		if v := storage[common.BigToHash(big.NewInt(1))]; v != common.BigToHash(big.NewInt(1000)) {
+			t.Errorf("%s: stored TIMESTAMP is %x", name, v)
+		}
+		if b := bt.post[contract].Balance; b == nil || b.Cmp(big.NewInt(1)) != 0 {
+			t.Errorf("%s: contract balance %v in post state, want 1", name, b)
+		}
+	}
+}
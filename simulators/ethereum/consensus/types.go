@@ -14,6 +14,20 @@ import (
 // A BlockTest checks handling of entire blocks.
 type BlockTest struct {
 	json btJSON
+	post core.GenesisAlloc // expected post-state of converted state tests
+}
+
+// postState decodes the expected post-state of the test. It returns nil if the test
+// has no post-state.
+func (t *BlockTest) postState() (core.GenesisAlloc, error) {
+	if t.post != nil || len(t.json.Post) == 0 {
+		return t.post, nil
+	}
+	var alloc core.GenesisAlloc
+	if err := json.Unmarshal(t.json.Post, &alloc); err != nil {
+		return nil, err
+	}
+	return alloc, nil
 }
 
 // UnmarshalJSON implements json.Unmarshaler interface.
@@ -25,7 +39,7 @@ type btJSON struct {
 	Blocks     []btBlock             `json:"blocks"`
 	Genesis    btHeader              `json:"genesisBlockHeader"`
 	Pre        core.GenesisAlloc     `json:"pre"`
-	Post       core.GenesisAlloc     `json:"-"`
+	Post       json.RawMessage       `json:"postState"` // decoded only when checked
 	BestBlock  common.UnprefixedHash `json:"lastblockhash"`
 	Network    string                `json:"network"`
 	SealEngine string                `json:"sealEngine"`
@@ -0,0 +1,8 @@
+module github.com/ethereum/hive/simulators/ethereum/hardfork
+
+go 1.16
+
+require (
+	github.com/ethereum/go-ethereum v1.10.8
+	github.com/ethereum/hive v0.0.0-20210901190357-cd81b24b2ac6
+)
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/hive/hivesim"
+)
+
+const (
+	berlinBlock = 20
+	londonBlock = 30
+
+	// The test runs until all nodes have this many blocks after the London fork.
+	blocksAfterLondon = 5
+)
+
+var (
+	// the amount of time nodes have to reach the final block
+	headTimeout = 3 * time.Minute
+
+	clientParams = hivesim.Params{
+		"HIVE_NETWORK_ID":          "19763",
+		"HIVE_CHAIN_ID":            "19763",
+		"HIVE_FORK_HOMESTEAD":      "0",
+		"HIVE_FORK_TANGERINE":      "0",
+		"HIVE_FORK_SPURIOUS":       "0",
+		"HIVE_FORK_BYZANTIUM":      "0",
+		"HIVE_FORK_CONSTANTINOPLE": "0",
+		"HIVE_FORK_PETERSBURG":     "0",
+		"HIVE_FORK_ISTANBUL":       "0",
+		"HIVE_FORK_BERLIN":         strconv.Itoa(berlinBlock),
+		"HIVE_FORK_LONDON":         strconv.Itoa(londonBlock),
+		"HIVE_CLIQUE_PERIOD":       "2",
+	}
+	// The signer uses the clique key which is authorized in genesis.
+	signerParams = clientParams.
+			Set("HIVE_CLIQUE_PRIVATEKEY", "9c647b8b7c4e7c3490668fb6c11473619db80c93704c70893d3813af4090c39c").
+			Set("HIVE_MINER", "658bdf435d810c91414ec09147daa6db62406379")
+	files = map[string]string{
+		"/genesis.json": "./init/genesis.json",
+	}
+)
+
+func main() {
+	suite := hivesim.Suite{
+		Name: "hardfork",
+		Description: `This suite checks live hard-fork transitions in a clique network of mixed clients.
+The network passes the Berlin and London forks while transactions specific to each fork
+are sent. All nodes must stay on the same chain.`,
+	}
+	suite.Add(hivesim.TestSpec{
+		Name:        "fork transition",
+		Description: "This test runs the Berlin and London transition with every eth1 client as the clique signer.",
+		Run:         transitionTest,
+	})
+	hivesim.MustRunSuite(hivesim.New(), suite)
+}
+
+// transitionTest runs a network for every client type as signer.
+func transitionTest(t *hivesim.T) {
+	clientTypes, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatal("can't get client types:", err)
+	}
+	var names []string
+	for _, c := range clientTypes {
+		if c.HasRole("eth1") {
+			names = append(names, c.Name)
+		}
+	}
+	for _, signer := range names {
+		signer := signer
+		t.Run(hivesim.TestSpec{
+			Name:        fmt.Sprintf("Berlin and London transition (signer: %s)", signer),
+			Description: fmt.Sprintf("A %s node signs blocks. One node of every eth1 client type follows the chain.", signer),
+			Run: func(t *hivesim.T) {
+				runNetwork(t, signer, names)
+			},
+		})
+	}
+}
+
+// runNetwork starts the followers, then the signer, and drives the test.
+func runNetwork(t *hivesim.T, signerType string, followerTypes []string) {
+	// Followers are started first and connected in a line, so all of them are in the
+	// network before the first block is signed.
+	var nodes []*hivesim.Client
+	params := clientParams
+	for _, typ := range followerTypes {
+		c := t.StartClient(typ, params, hivesim.WithStaticFiles(files))
+		nodes = append(nodes, c)
+		enode, err := c.EnodeURL()
+		if err != nil {
+			t.Fatalf("can't get enode URL of %s: %v", typ, err)
+		}
+		params = clientParams.Set("HIVE_BOOTNODE", enode)
+	}
+	signer := t.StartClient(signerType, signerParams.Set("HIVE_BOOTNODE", params["HIVE_BOOTNODE"]), hivesim.WithStaticFiles(files))
+	nodes = append([]*hivesim.Client{signer}, nodes...)
+
+	// Send transactions across the fork boundaries.
+	s := newSender(t, ethclient.NewClient(signer.RPC()))
+	s.run()
+
+	// Wait for all nodes to reach the final block.
+	final := uint64(londonBlock + blocksAfterLondon)
+	for _, c := range nodes {
+		if err := waitForBlock(c, final); err != nil {
+			t.Errorf("%s: %v", nodeName(c), err)
+		}
+	}
+	if err := checkDivergence(nodes, final); err != nil {
+		t.Fatal(err)
+	}
+	s.checkReceipts(nodes)
+}
+
+// waitForBlock waits until the client has imported the given block number.
+func waitForBlock(c *hivesim.Client, number uint64) error {
+	client := ethclient.NewClient(c.RPC())
+	deadline := time.Now().Add(headTimeout)
+	var head uint64
+	for time.Now().Before(deadline) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		h, err := client.HeaderByNumber(ctx, nil)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("can't query chain head: %v", err)
+		}
+		head = h.Number.Uint64()
+		if head >= number {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("timeout waiting for block %d, head is %d", number, head)
+}
+
+// checkDivergence compares the canonical chains of all nodes up to the given block and
+// reports the first block where the nodes disagree.
+func checkDivergence(nodes []*hivesim.Client, final uint64) error {
+	clients := make([]*ethclient.Client, len(nodes))
+	for i, c := range nodes {
+		clients[i] = ethclient.NewClient(c.RPC())
+	}
+	for n := uint64(1); n <= final; n++ {
+		hashes := make(map[common.Hash][]string)
+		for i, client := range clients {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			h, err := client.HeaderByNumber(ctx, bigUint(n))
+			cancel()
+			hash := common.Hash{}
+			if err == nil {
+				hash = h.Hash()
+			}
+			hashes[hash] = append(hashes[hash], nodeName(nodes[i]))
+		}
+		if len(hashes) > 1 {
+			var desc []string
+			for hash, names := range hashes {
+				if hash == (common.Hash{}) {
+					desc = append(desc, fmt.Sprintf("missing on %s", strings.Join(names, ", ")))
+				} else {
+					desc = append(desc, fmt.Sprintf("%s on %s", hash.TerminalString(), strings.Join(names, ", ")))
+				}
+			}
+			return fmt.Errorf("nodes diverge at block %d%s: %s", n, forkNote(n), strings.Join(desc, "; "))
+		}
+	}
+	return nil
+}
+
+// forkNote describes the block's position relative to the forks.
+func forkNote(n uint64) string {
+	switch n {
+	case berlinBlock:
+		return " (Berlin fork block)"
+	case londonBlock:
+		return " (London fork block)"
+	}
+	return ""
+}
+
+func nodeName(c *hivesim.Client) string {
+	return fmt.Sprintf("%s (%s)", c.Type, c.Container[:8])
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/hive/hivesim"
+)
+
+var (
+	chainID = big.NewInt(19763)
+
+	// senderKey is funded in genesis.
+	senderKey, _ = crypto.HexToECDSA("fe461248b0698a1bff0fd764aba24a4a3f91d9155649c6665c55e0188aca83cb")
+	recipient    = common.HexToAddress("0x0000000000000000000000000000000000001234")
+
+	gasPrice = big.NewInt(30 * params.GWei)
+	gasTip   = big.NewInt(params.GWei)
+)
+
+// sender submits transactions to the signer while the chain passes the forks.
+type sender struct {
+	t      *hivesim.T
+	client *ethclient.Client
+	key    *ecdsa.PrivateKey
+	signer types.Signer
+	nonce  uint64
+	sent   []*types.Transaction
+}
+
+func newSender(t *hivesim.T, client *ethclient.Client) *sender {
+	return &sender{
+		t:      t,
+		client: client,
+		key:    senderKey,
+		signer: types.LatestSignerForChainID(chainID),
+	}
+}
+
+// run sends transactions until the London transactions are submitted. Typed transactions
+// are sent before their fork to check that they are rejected, and right before the fork
+// block, so they are included in the first block of the fork.
+func (s *sender) run() {
+	var (
+		preBerlinDone, berlinDone bool
+		preLondonDone, londonDone bool
+		deadline                  = time.Now().Add(headTimeout)
+	)
+	for !londonDone {
+		if time.Now().After(deadline) {
+			s.t.Fatal("timeout waiting for the London fork")
+		}
+		head, err := s.head()
+		if err != nil {
+			s.t.Fatal("can't query signer head:", err)
+		}
+		switch {
+		case !preBerlinDone && head+2 < berlinBlock:
+			s.send("legacy transaction before Berlin", s.legacyTx())
+			s.sendRejected("access list transaction before Berlin", s.accessListTx())
+			s.sendRejected("dynamic fee transaction before Berlin", s.dynamicFeeTx())
+			preBerlinDone = true
+		case !berlinDone && head+1 >= berlinBlock:
+			if head >= berlinBlock {
+				s.t.Logf("chain passed Berlin (head %d) before transactions were sent", head)
+			}
+			s.send("legacy transaction at Berlin", s.legacyTx())
+			s.send("access list transaction at Berlin", s.accessListTx())
+			berlinDone = true
+		case berlinDone && !preLondonDone && head+2 < londonBlock:
+			s.sendRejected("dynamic fee transaction before London", s.dynamicFeeTx())
+			preLondonDone = true
+		case !londonDone && head+1 >= londonBlock:
+			if head >= londonBlock {
+				s.t.Logf("chain passed London (head %d) before transactions were sent", head)
+			}
+			s.send("legacy transaction at London", s.legacyTx())
+			s.send("access list transaction at London", s.accessListTx())
+			s.send("dynamic fee transaction at London", s.dynamicFeeTx())
+			londonDone = true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// send submits a transaction which must be accepted.
+func (s *sender) send(desc string, txdata types.TxData) {
+	tx := types.MustSignNewTx(s.key, s.signer, txdata)
+	if err := s.client.SendTransaction(context.Background(), tx); err != nil {
+		s.t.Errorf("%s rejected: %v", desc, err)
+		return
+	}
+	s.t.Logf("sent %s %x", desc, tx.Hash())
+	s.sent = append(s.sent, tx)
+	s.nonce++
+}
+
+// sendRejected submits a transaction which must be rejected because its type is not
+// valid before the fork.
+func (s *sender) sendRejected(desc string, txdata types.TxData) {
+	tx := types.MustSignNewTx(s.key, s.signer, txdata)
+	if err := s.client.SendTransaction(context.Background(), tx); err == nil {
+		s.t.Errorf("%s was accepted", desc)
+		s.sent = append(s.sent, tx)
+		s.nonce++
+	}
+}
+
+func (s *sender) head() (uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	h, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	return h.Number.Uint64(), nil
+}
+
+func (s *sender) legacyTx() types.TxData {
+	return &types.LegacyTx{
+		Nonce:    s.nonce,
+		GasPrice: gasPrice,
+		Gas:      params.TxGas,
+		To:       &recipient,
+		Value:    big.NewInt(1),
+	}
+}
+
+func (s *sender) accessListTx() types.TxData {
+	return &types.AccessListTx{
+		ChainID:  chainID,
+		Nonce:    s.nonce,
+		GasPrice: gasPrice,
+		Gas:      30000,
+		To:       &recipient,
+		Value:    big.NewInt(1),
+		AccessList: types.AccessList{
+			{Address: recipient, StorageKeys: []common.Hash{{0x01}}},
+		},
+	}
+}
+
+func (s *sender) dynamicFeeTx() types.TxData {
+	return &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     s.nonce,
+		GasTipCap: gasTip,
+		GasFeeCap: gasPrice,
+		Gas:       30000,
+		To:        &recipient,
+		Value:     big.NewInt(1),
+		AccessList: types.AccessList{
+			{Address: recipient, StorageKeys: []common.Hash{{0x01}}},
+		},
+	}
+}
+
+// checkReceipts verifies that all sent transactions are included in the same block
+// and executed successfully on all nodes.
+func (s *sender) checkReceipts(nodes []*hivesim.Client) {
+	for _, tx := range s.sent {
+		var blockHash common.Hash
+		for _, c := range nodes {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			receipt, err := ethclient.NewClient(c.RPC()).TransactionReceipt(ctx, tx.Hash())
+			cancel()
+			if err != nil {
+				s.t.Errorf("%s: can't get receipt of type %d transaction %x: %v", nodeName(c), tx.Type(), tx.Hash(), err)
+				continue
+			}
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				s.t.Errorf("%s: type %d transaction %x failed in block %d", nodeName(c), tx.Type(), tx.Hash(), receipt.BlockNumber)
+			}
+			if blockHash == (common.Hash{}) {
+				blockHash = receipt.BlockHash
+				s.t.Logf("type %d transaction %x included in block %d%s", tx.Type(), tx.Hash(), receipt.BlockNumber, forkNote(receipt.BlockNumber.Uint64()))
+			} else if receipt.BlockHash != blockHash {
+				s.t.Errorf("%s: transaction %x in block %x, want %x", nodeName(c), tx.Hash(), receipt.BlockHash, blockHash)
+			}
+		}
+	}
+}
+
+// bigUint converts a block number for use with ethclient.
+func bigUint(n uint64) *big.Int {
+	return new(big.Int).SetUint64(n)
+}
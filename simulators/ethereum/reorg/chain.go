@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// commonLength is the number of blocks shared by both branches.
+const commonLength = 10
+
+var (
+	coinbaseA = common.HexToAddress("0x000000000000000000000000000000000000000a")
+	coinbaseB = common.HexToAddress("0x000000000000000000000000000000000000000b")
+)
+
+// chainConfig is the chain configuration of the generated chains. It must match the
+// HIVE_FORK_* parameters given to clients.
+var chainConfig = &params.ChainConfig{
+	ChainID:             big.NewInt(19763),
+	HomesteadBlock:      big.NewInt(0),
+	EIP150Block:         big.NewInt(0),
+	EIP155Block:         big.NewInt(0),
+	EIP158Block:         big.NewInt(0),
+	ByzantiumBlock:      big.NewInt(0),
+	ConstantinopleBlock: big.NewInt(0),
+	PetersburgBlock:     big.NewInt(0),
+	IstanbulBlock:       big.NewInt(0),
+	Ethash:              new(params.EthashConfig),
+}
+
+// branchSpec describes one side of a fork.
+type branchSpec struct {
+	length    int // number of blocks after the common ancestor
+	blockTime int // seconds between blocks, influences difficulty
+}
+
+// forkedChain is a chain with two competing branches on top of a common prefix.
+type forkedChain struct {
+	genesis *core.Genesis
+	common  []*types.Block
+	a, b    []*types.Block
+}
+
+// makeForkedChain generates the common prefix and both branches. Blocks are sealed by
+// the fake ethash engine, so clients must be started with HIVE_SKIP_POW.
+func makeForkedChain(a, b branchSpec) (*forkedChain, error) {
+	gspec := &core.Genesis{
+		Config:     chainConfig,
+		Difficulty: big.NewInt(0x1000000),
+		GasLimit:   0x2fefd8,
+		Timestamp:  0x5c938e00,
+		ExtraData:  []byte("hive reorg"),
+		Alloc:      core.GenesisAlloc{},
+	}
+	var (
+		db      = rawdb.NewMemoryDatabase()
+		engine  = ethash.NewFaker()
+		genesis = gspec.MustCommit(db)
+	)
+	prefix, _ := core.GenerateChain(chainConfig, genesis, engine, db, commonLength, nil)
+	ancestor := prefix[len(prefix)-1]
+	branchA, _ := core.GenerateChain(chainConfig, ancestor, engine, db, a.length, branchGenerator(coinbaseA, a.blockTime))
+	branchB, _ := core.GenerateChain(chainConfig, ancestor, engine, db, b.length, branchGenerator(coinbaseB, b.blockTime))
+	if len(branchA) != a.length || len(branchB) != b.length {
+		return nil, fmt.Errorf("chain generation failed")
+	}
+	return &forkedChain{genesis: gspec, common: prefix, a: branchA, b: branchB}, nil
+}
+
+// branchGenerator returns a block modifier that sets the coinbase and block time.
+func branchGenerator(coinbase common.Address, blockTime int) func(int, *core.BlockGen) {
+	return func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(coinbase)
+		// GenerateChain uses a fixed block time of 10s.
+		gen.OffsetTime(int64(blockTime - 10))
+	}
+}
+
+// td computes the total difficulty of the given branch.
+func (c *forkedChain) td(branch []*types.Block) *big.Int {
+	td := new(big.Int).Set(c.genesis.Difficulty)
+	for _, b := range c.common {
+		td.Add(td, b.Difficulty())
+	}
+	for _, b := range branch {
+		td.Add(td, b.Difficulty())
+	}
+	return td
+}
+
+// heaviest returns the head block of the branch with the highest total difficulty.
+func (c *forkedChain) heaviest() (*types.Block, error) {
+	tdA, tdB := c.td(c.a), c.td(c.b)
+	switch tdA.Cmp(tdB) {
+	case 1:
+		return c.a[len(c.a)-1], nil
+	case -1:
+		return c.b[len(c.b)-1], nil
+	default:
+		return nil, fmt.Errorf("both branches have total difficulty %v", tdA)
+	}
+}
+
+// reorgDepth returns the number of blocks a client must roll back when it has imported
+// branch A first and then learns about the heaviest branch.
+func (c *forkedChain) reorgDepth() int {
+	if c.td(c.b).Cmp(c.td(c.a)) > 0 {
+		return len(c.a)
+	}
+	return 0
+}
+
+// chainFiles writes the client input files to dir. The genesis and common prefix go into
+// genesis.json and chain.rlp. If branches contains more than one branch, they are written
+// as individual blocks into the blocks/ directory, in order. Otherwise the single branch
+// is appended to chain.rlp.
+func (c *forkedChain) chainFiles(dir string, branches ...[]*types.Block) (map[string]string, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "blocks"), 0755); err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+
+	genesisFile := filepath.Join(dir, "genesis.json")
+	genesisJSON, err := json.Marshal(c.genesis)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(genesisFile, genesisJSON, 0644); err != nil {
+		return nil, err
+	}
+	files["/genesis.json"] = genesisFile
+
+	chain := c.common
+	if len(branches) == 1 {
+		chain = append(chain[:len(chain):len(chain)], branches[0]...)
+		branches = nil
+	}
+	chainFile := filepath.Join(dir, "chain.rlp")
+	if err := writeBlocks(chainFile, chain); err != nil {
+		return nil, err
+	}
+	files["/chain.rlp"] = chainFile
+
+	var n int
+	for _, branch := range branches {
+		for _, block := range branch {
+			n++
+			name := fmt.Sprintf("%04d.rlp", n)
+			blockFile := filepath.Join(dir, "blocks", name)
+			if err := writeBlocks(blockFile, []*types.Block{block}); err != nil {
+				return nil, err
+			}
+			files["/blocks/"+name] = blockFile
+		}
+	}
+	return files, nil
+}
+
+// writeBlocks writes RLP-encoded blocks to the given file.
+func writeBlocks(file string, blocks []*types.Block) error {
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	for _, block := range blocks {
+		if err := rlp.Encode(out, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
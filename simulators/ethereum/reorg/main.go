@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/hive/hivesim"
+)
+
+var (
+	// the amount of time a client has to switch to the expected head
+	headTimeout  = 60 * time.Second
+	clientParams = hivesim.Params{
+		"HIVE_NETWORK_ID":          "19763",
+		"HIVE_CHAIN_ID":            "19763",
+		"HIVE_FORK_HOMESTEAD":      "0",
+		"HIVE_FORK_TANGERINE":      "0",
+		"HIVE_FORK_SPURIOUS":       "0",
+		"HIVE_FORK_BYZANTIUM":      "0",
+		"HIVE_FORK_CONSTANTINOPLE": "0",
+		"HIVE_FORK_PETERSBURG":     "0",
+		"HIVE_FORK_ISTANBUL":       "0",
+		"HIVE_SKIP_POW":            "1",
+	}
+)
+
+// scenario is a pair of competing branches.
+type scenario struct {
+	name        string
+	description string
+	a, b        branchSpec
+}
+
+var scenarios = []scenario{
+	{
+		name:        "longer branch",
+		description: "Branch B has one block more than branch A at equal block time, and is therefore heavier.",
+		a:           branchSpec{length: 2, blockTime: 10},
+		b:           branchSpec{length: 3, blockTime: 10},
+	},
+	{
+		name:        "shorter heavier branch",
+		description: "Branch B is one block shorter than branch A, but its blocks are mined faster and have higher difficulty.",
+		a:           branchSpec{length: 41, blockTime: 30},
+		b:           branchSpec{length: 40, blockTime: 1},
+	},
+	{
+		name:        "longer lighter branch",
+		description: "Branch B is one block longer than branch A, but its blocks are mined slower and have lower difficulty. Clients must stay on branch A.",
+		a:           branchSpec{length: 40, blockTime: 1},
+		b:           branchSpec{length: 41, blockTime: 30},
+	},
+}
+
+// depths are the reorg depths checked by the depth limit test.
+var depths = []int{1, 8, 32, 64, 128, 256}
+
+func main() {
+	suite := hivesim.Suite{
+		Name: "reorg",
+		Description: `This suite checks fork choice between two competing branches with a common ancestor.
+Branches are provided to the client through block import and through the eth protocol.
+The client must always select the branch with the highest total difficulty.`,
+	}
+	for _, sc := range scenarios {
+		suite.Add(hivesim.TestSpec{
+			Name:        "import: " + sc.name,
+			Description: sc.description + "\n\nThe client imports branch A, then branch B from the /blocks directory.",
+			Run:         sc.importTest,
+		})
+		suite.Add(hivesim.TestSpec{
+			Name:        "devp2p: " + sc.name,
+			Description: sc.description + "\n\nTwo clients start on different branches and are connected to each other.",
+			Run:         sc.peerTest,
+		})
+	}
+	suite.Add(hivesim.TestSpec{
+		Name: "reorg depth limit",
+		Description: `This test measures how deep a reorg each client performs when importing blocks.
+For every depth, a client imports a branch of that length, then a heavier competing branch.`,
+		Run: depthLimitTest,
+	})
+	hivesim.MustRunSuite(hivesim.New(), suite)
+}
+
+// importTest checks fork choice when both branches are imported from files.
+func (sc scenario) importTest(t *hivesim.T) {
+	chain, want := sc.generate(t)
+	files, dir := writeFiles(t, chain, chain.a, chain.b)
+	defer os.RemoveAll(dir)
+	t.RunAllClients(hivesim.ClientTestSpec{
+		Role:        "eth1",
+		Name:        fmt.Sprintf("import: %s (CLIENT)", sc.name),
+		Description: sc.description,
+		Parameters:  clientParams,
+		Files:       files,
+		Run: func(t *hivesim.T, c *hivesim.Client) {
+			if err := waitForHead(c, want); err != nil {
+				t.Fatal(err)
+			}
+		},
+	})
+}
+
+// peerTest checks fork choice when the branches are exchanged over devp2p.
+func (sc scenario) peerTest(t *hivesim.T) {
+	chain, want := sc.generate(t)
+	filesA, dirA := writeFiles(t, chain, chain.a)
+	defer os.RemoveAll(dirA)
+	filesB, dirB := writeFiles(t, chain, chain.b)
+	defer os.RemoveAll(dirB)
+
+	clientTypes, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatal("can't get client types:", err)
+	}
+	for _, source := range clientTypes {
+		if !source.HasRole("eth1") {
+			continue
+		}
+		source := source.Name
+		t.RunAllClients(hivesim.ClientTestSpec{
+			Role:        "eth1",
+			Name:        fmt.Sprintf("devp2p: %s (%s -> CLIENT)", sc.name, source),
+			Description: sc.description,
+			Parameters:  clientParams,
+			Files:       filesB,
+			Run: func(t *hivesim.T, sink *hivesim.Client) {
+				// The sink is already running on branch B. Start the source on branch A
+				// and make it dial the sink.
+				enode, err := sink.EnodeURL()
+				if err != nil {
+					t.Fatal("can't get sink enode URL:", err)
+				}
+				src := t.StartClient(source, clientParams.Set("HIVE_BOOTNODE", enode), hivesim.WithStaticFiles(filesA))
+				for _, c := range []*hivesim.Client{src, sink} {
+					if err := waitForHead(c, want); err != nil {
+						t.Errorf("%s (%s): %v", c.Type, c.Container, err)
+					}
+				}
+			},
+		})
+	}
+}
+
+// depthLimitTest runs imports of increasing reorg depth against all clients.
+func depthLimitTest(t *hivesim.T) {
+	clientTypes, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatal("can't get client types:", err)
+	}
+	for _, client := range clientTypes {
+		if !client.HasRole("eth1") {
+			continue
+		}
+		var maxDepth int
+		for _, depth := range depths {
+			sc := scenario{
+				a: branchSpec{length: depth, blockTime: 10},
+				b: branchSpec{length: depth + 1, blockTime: 10},
+			}
+			chain, want := sc.generate(t)
+			files, dir := writeFiles(t, chain, chain.a, chain.b)
+			passed := false
+			t.RunClient(client.Name, hivesim.ClientTestSpec{
+				Name:        fmt.Sprintf("reorg depth %d (%s)", depth, client.Name),
+				Description: fmt.Sprintf("The client imports %d blocks, then a heavier branch of %d blocks.", depth, depth+1),
+				Parameters:  clientParams,
+				Files:       files,
+				Run: func(t *hivesim.T, c *hivesim.Client) {
+					if err := waitForHead(c, want); err != nil {
+						t.Fatal(err)
+					}
+					passed = true
+				},
+			})
+			os.RemoveAll(dir)
+			if passed {
+				maxDepth = depth
+			}
+		}
+		t.Logf("%s: maximum reorg depth %d", client.Name, maxDepth)
+	}
+}
+
+// generate creates the chain of a scenario and returns the expected head.
+func (sc scenario) generate(t *hivesim.T) (*forkedChain, *types.Block) {
+	chain, err := makeForkedChain(sc.a, sc.b)
+	if err != nil {
+		t.Fatal("can't generate chain:", err)
+	}
+	want, err := chain.heaviest()
+	if err != nil {
+		t.Fatal("invalid scenario:", err)
+	}
+	t.Logf("branch A: %d blocks, td %v", len(chain.a), chain.td(chain.a))
+	t.Logf("branch B: %d blocks, td %v", len(chain.b), chain.td(chain.b))
+	t.Logf("expected head: %d (%s), reorg depth %d", want.NumberU64(), want.Hash().TerminalString(), chain.reorgDepth())
+	return chain, want
+}
+
+// writeFiles writes the client input files to a new temporary directory. The caller
+// must remove the directory when the files are no longer needed.
+func writeFiles(t *hivesim.T, chain *forkedChain, branches ...[]*types.Block) (map[string]string, string) {
+	dir, err := ioutil.TempDir("", "reorg")
+	if err != nil {
+		t.Fatal("can't create chain directory:", err)
+	}
+	files, err := chain.chainFiles(dir, branches...)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal("can't write chain files:", err)
+	}
+	return files, dir
+}
+
+// waitForHead waits for the client to reach the given head block.
+func waitForHead(c *hivesim.Client, want *types.Block) error {
+	var (
+		client   = ethclient.NewClient(c.RPC())
+		deadline = time.Now().Add(headTimeout)
+		head     *types.Header
+	)
+	for time.Now().Before(deadline) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		h, err := client.HeaderByNumber(ctx, nil)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("can't query chain head: %v", err)
+		}
+		head = h
+		if head.Hash() == want.Hash() {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("wrong chain head %d (%s), want %d (%s)", head.Number, head.Hash().TerminalString(), want.Number(), want.Hash().TerminalString())
+}
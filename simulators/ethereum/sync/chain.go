@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// These limit the number of accounts and transactions checked after sync.
+const (
+	maxSampledAccounts     = 16
+	maxSampledTransactions = 16
+)
+
+// testChain holds the expected content of the test chain.
+type testChain struct {
+	accounts map[common.Address]*big.Int // balances at head
+	txs      []chainTx
+}
+
+type chainTx struct {
+	hash      common.Hash
+	blockHash common.Hash
+}
+
+// loadTestChain reads chain.rlp and chain_poststate.json in dir.
+func loadTestChain(dir string) (*testChain, error) {
+	var dump struct {
+		Accounts map[common.Address]struct {
+			Balance string `json:"balance"`
+		} `json:"accounts"`
+	}
+	if err := common.LoadJSON(filepath.Join(dir, "chain_poststate.json"), &dump); err != nil {
+		return nil, err
+	}
+	chain := &testChain{accounts: make(map[common.Address]*big.Int)}
+	for addr, acc := range dump.Accounts {
+		balance, ok := new(big.Int).SetString(acc.Balance, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance %q of account %x", acc.Balance, addr)
+		}
+		chain.accounts[addr] = balance
+	}
+
+	file, err := os.Open(filepath.Join(dir, "chain.rlp"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	s := rlp.NewStream(bufio.NewReader(file), 0)
+	for i := 0; ; i++ {
+		var block types.Block
+		err := s.Decode(&block)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("block %d: %v", i, err)
+		}
+		for _, tx := range block.Transactions() {
+			chain.txs = append(chain.txs, chainTx{hash: tx.Hash(), blockHash: block.Hash()})
+		}
+	}
+	return chain, nil
+}
+
+// sampleAccounts returns some accounts of the post-state.
+func (c *testChain) sampleAccounts() []common.Address {
+	addrs := make([]common.Address, 0, len(c.accounts))
+	for addr := range c.accounts {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Hex() < addrs[j].Hex() })
+	if len(addrs) > maxSampledAccounts {
+		addrs = addrs[:maxSampledAccounts]
+	}
+	return addrs
+}
+
+// balance returns the expected balance of an account.
+func (c *testChain) balance(addr common.Address) *big.Int {
+	return c.accounts[addr]
+}
+
+// sampleTransactions returns transactions spread evenly across the chain.
+func (c *testChain) sampleTransactions() []chainTx {
+	txs := make([]chainTx, 0, maxSampledTransactions)
+	step := len(c.txs)/maxSampledTransactions + 1
+	for i := 0; i < len(c.txs); i += step {
+		txs = append(txs, c.txs[i])
+	}
+	return txs
+}
@@ -3,6 +3,6 @@ module github.com/ethereum/hive/simulators/ethereum/sync
 go 1.13
 
 require (
-	github.com/ethereum/go-ethereum v1.9.23
-	github.com/ethereum/hive v0.0.0-20201104200225-0ce0954f3916
+	github.com/ethereum/go-ethereum v1.10.4
+	github.com/ethereum/hive v0.0.0-20210901190357-cd81b24b2ac6
 )
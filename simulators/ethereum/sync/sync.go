@@ -21,9 +21,10 @@ var (
 		"HIVE_FORK_TANGERINE": "0",
 		"HIVE_FORK_SPURIOUS":  "0",
 		"HIVE_FORK_BYZANTIUM": "0",
-		"HIVE_NODETYPE":       "full",
 	}
-	sourceFiles = map[string]string{
+	// Sources run as archive nodes and serve light clients if they can.
+	sourceParams = params.Set("HIVE_NODETYPE", "archive").Set("HIVE_LES_SERVER", "1")
+	sourceFiles  = map[string]string{
 		"genesis.json": "./simplechain/genesis.json",
 		"chain.rlp":    "./simplechain/chain.rlp",
 	}
@@ -37,16 +38,33 @@ var (
 	testchainHeadHash   = common.HexToHash("0xc95596f4707fb382554b660b4847c599eb5f8fdcf99be2c5654aaadd4ec97840")
 )
 
+// syncMode is a sync algorithm selected through HIVE_NODETYPE.
+type syncMode struct {
+	nodeType   string // value of HIVE_NODETYPE
+	role       string // role declaring support for the mode on the sink
+	sourceRole string // role required of the source, if any
+}
+
+var syncModes = []syncMode{
+	{nodeType: "full", role: "eth1"},
+	{nodeType: "fast", role: "eth1_fast_sync"},
+	{nodeType: "snap", role: "eth1_snap_sync"},
+	{nodeType: "light", role: "eth1_light_client", sourceRole: "eth1_light_server"},
+}
+
 func main() {
 	var suite = hivesim.Suite{
 		Name: "sync",
 		Description: `This suite of tests verifies that clients can sync from each other in different modes.
-For each client, we test if it can serve as a sync source for all other clients (including itself).`,
+For each client, we test if it can serve as a sync source for all other clients (including itself).
+Sync modes other than full sync are tested only against clients which declare support for them
+using the eth1_fast_sync, eth1_snap_sync and eth1_light_client roles.`,
 	}
 	suite.Add(hivesim.ClientTestSpec{
+		Role:        "eth1",
 		Name:        "CLIENT as sync source",
 		Description: "This loads the test chain into the client and verifies whether it was imported correctly.",
-		Parameters:  params,
+		Parameters:  sourceParams,
 		Files:       sourceFiles,
 		Run:         runSourceTest,
 	})
@@ -59,30 +77,67 @@ func runSourceTest(t *hivesim.T, c *hivesim.Client) {
 	if err := source.checkHead(testchainHeadNumber, testchainHeadHash); err != nil {
 		t.Fatal(err)
 	}
+	chain, err := loadTestChain("./simplechain")
+	if err != nil {
+		t.Fatal("can't load test chain:", err)
+	}
+	sourceDef, err := clientDefinition(t, source.Type)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Configure sink to connect to the source node.
 	enode, err := source.EnodeURL()
 	if err != nil {
 		t.Fatal("can't get node peer-to-peer endpoint:", enode)
 	}
-	sinkParams := params.Set("HIVE_BOOTNODE", enode)
 
-	// Sync all sink nodes against the source.
-	t.RunAllClients(hivesim.ClientTestSpec{
-		Name:        fmt.Sprintf("sync %s -> CLIENT", source.Type),
-		Description: fmt.Sprintf("This test attempts to sync the chain from a %s node.", source.Type),
-		Parameters:  sinkParams,
-		Files:       sinkFiles,
-		Run:         runSyncTest,
-	})
+	// Sync all sink nodes against the source, in all modes they support.
+	for _, mode := range syncModes {
+		if mode.sourceRole != "" && !sourceDef.HasRole(mode.sourceRole) {
+			t.Logf("skipping %s sync: %s does not have role %s", mode.nodeType, source.Type, mode.sourceRole)
+			continue
+		}
+		sinkParams := params.Set("HIVE_BOOTNODE", enode).Set("HIVE_NODETYPE", mode.nodeType)
+		t.RunAllClients(hivesim.ClientTestSpec{
+			Role:        mode.role,
+			Name:        fmt.Sprintf("%s sync %s -> CLIENT", mode.nodeType, source.Type),
+			Description: fmt.Sprintf("This test attempts to %s sync the chain from a %s node.", mode.nodeType, source.Type),
+			Parameters:  sinkParams,
+			Files:       sinkFiles,
+			Run: func(t *hivesim.T, c *hivesim.Client) {
+				runSyncTest(t, c, chain)
+			},
+		})
+	}
 }
 
-func runSyncTest(t *hivesim.T, c *hivesim.Client) {
+func runSyncTest(t *hivesim.T, c *hivesim.Client, chain *testChain) {
 	node := &node{c}
 	err := node.checkSync(t, testchainHeadNumber, testchainHeadHash)
 	if err != nil {
 		t.Fatal("sync failed:", err)
 	}
+	if err := node.checkState(chain); err != nil {
+		t.Error("state check failed:", err)
+	}
+	if err := node.checkReceipts(chain); err != nil {
+		t.Error("receipt check failed:", err)
+	}
+}
+
+// clientDefinition finds the definition of the given client type.
+func clientDefinition(t *hivesim.T, clientType string) (*hivesim.ClientDefinition, error) {
+	clients, err := t.Sim.ClientTypes()
+	if err != nil {
+		return nil, fmt.Errorf("can't get client types: %v", err)
+	}
+	for _, def := range clients {
+		if def.Name == clientType {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown client type %q", clientType)
 }
 
 type node struct {
@@ -124,10 +179,45 @@ func (n *node) checkSync(t *hivesim.T, wantNumber uint64, wantHash common.Hash)
 
 // head returns the node's chain head.
 func (n *node) head() (*types.Header, error) {
-	ctx, _ := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 	return ethclient.NewClient(n.RPC()).HeaderByNumber(ctx, nil)
 }
 
+// checkState compares account balances at the head block against the expected post-state.
+func (n *node) checkState(chain *testChain) error {
+	client := ethclient.NewClient(n.RPC())
+	for _, addr := range chain.sampleAccounts() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		balance, err := client.BalanceAt(ctx, addr, nil)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("can't get balance of %x: %v", addr, err)
+		}
+		if want := chain.balance(addr); balance.Cmp(want) != 0 {
+			return fmt.Errorf("wrong balance of %x: %v, want %v", addr, balance, want)
+		}
+	}
+	return nil
+}
+
+// checkReceipts verifies that receipts of sampled transactions are available.
+func (n *node) checkReceipts(chain *testChain) error {
+	client := ethclient.NewClient(n.RPC())
+	for _, tx := range chain.sampleTransactions() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		receipt, err := client.TransactionReceipt(ctx, tx.hash)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("can't get receipt of tx %x: %v", tx.hash, err)
+		}
+		if receipt.BlockHash != tx.blockHash {
+			return fmt.Errorf("receipt of tx %x has block hash %x, want %x", tx.hash, receipt.BlockHash, tx.blockHash)
+		}
+	}
+	return nil
+}
+
 // checkHead checks whether the remote chain head matches the given values.
 func (n *node) checkHead(num uint64, hash common.Hash) error {
 	head, err := n.head()
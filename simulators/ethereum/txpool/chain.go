@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// sourceChainLength is the number of blocks imported by the source node. The sink
+// starts at genesis and syncs these blocks, which makes it accept transactions
+// from the network.
+const sourceChainLength = 8
+
+// genesisTimestamp is the timestamp of the genesis block.
+const genesisTimestamp = 1600000000
+
+// chainConfig is the chain configuration of the generated chain. It must match the
+// HIVE_FORK_* parameters given to clients.
+var chainConfig = &params.ChainConfig{
+	ChainID:             big.NewInt(19763),
+	HomesteadBlock:      big.NewInt(0),
+	EIP150Block:         big.NewInt(0),
+	EIP155Block:         big.NewInt(0),
+	EIP158Block:         big.NewInt(0),
+	ByzantiumBlock:      big.NewInt(0),
+	ConstantinopleBlock: big.NewInt(0),
+	PetersburgBlock:     big.NewInt(0),
+	IstanbulBlock:       big.NewInt(0),
+	BerlinBlock:         big.NewInt(0),
+	Ethash:              new(params.EthashConfig),
+}
+
+// accounts are funded in genesis. Every check uses its own accounts, so nonces of
+// different checks do not interfere.
+var accounts = makeAccounts(4 + evictionTxs)
+
+func makeAccounts(n int) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, n)
+	for i := range keys {
+		keys[i], _ = crypto.ToECDSA(crypto.Keccak256([]byte{'t', 'x', 'p', 'o', 'o', 'l', byte(i)}))
+	}
+	return keys
+}
+
+// chainFiles generates the test chain and writes the client input files to dir. It
+// returns the files of the source and sink nodes.
+//
+// Clients only accept transactions from the network when their head block is
+// recent, so the first block is moved forward in time and the chain ends shortly
+// before the current time. The genesis block does not depend on the current time.
+// Blocks are sealed by the fake ethash engine and clients must be started with
+// HIVE_SKIP_POW.
+func chainFiles(dir string) (source, sink map[string]string, err error) {
+	alloc := make(core.GenesisAlloc)
+	balance := new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+	for _, key := range accounts {
+		alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{Balance: balance}
+	}
+	gspec := &core.Genesis{
+		Config:     chainConfig,
+		Difficulty: big.NewInt(0x20000),
+		GasLimit:   0x2fefd8,
+		Timestamp:  genesisTimestamp,
+		ExtraData:  []byte("hive txpool"),
+		Alloc:      alloc,
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := gspec.MustCommit(db)
+	start := time.Now().Add(-time.Duration(sourceChainLength+5) * 10 * time.Second).Unix()
+	blocks, _ := core.GenerateChain(chainConfig, genesis, ethash.NewFaker(), db, sourceChainLength, func(i int, b *core.BlockGen) {
+		// GenerateChain places blocks ten seconds after their parent.
+		if i == 0 {
+			b.OffsetTime(start - (genesisTimestamp + 10))
+		}
+	})
+
+	genesisFile := filepath.Join(dir, "genesis.json")
+	genesisJSON, err := json.Marshal(gspec)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := ioutil.WriteFile(genesisFile, genesisJSON, 0644); err != nil {
+		return nil, nil, err
+	}
+	chainFile := filepath.Join(dir, "chain.rlp")
+	if err := writeBlocks(chainFile, blocks); err != nil {
+		return nil, nil, err
+	}
+	source = map[string]string{"/genesis.json": genesisFile, "/chain.rlp": chainFile}
+	sink = map[string]string{"/genesis.json": genesisFile}
+	return source, sink, nil
+}
+
+// writeBlocks writes RLP-encoded blocks to the given file.
+func writeBlocks(file string, blocks []*types.Block) error {
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	for _, block := range blocks {
+		if err := rlp.Encode(out, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/hive/hivesim"
+)
+
+var (
+	// the amount of time a transaction has to reach the sink
+	propagationTimeout = 30 * time.Second
+	// the amount of time the sink has to sync the source chain
+	syncTimeout = 60 * time.Second
+	// the amount of time to wait before asserting that a transaction was not propagated
+	queuedWait = 10 * time.Second
+
+	// the transaction pool size of the sink in the eviction check
+	sinkPoolSize = 16
+	// the number of transactions sent in the eviction check
+	evictionTxs = sinkPoolSize + 4
+
+	gasPrice     = big.NewInt(params.GWei)
+	clientParams = hivesim.Params{
+		"HIVE_NETWORK_ID":          "19763",
+		"HIVE_CHAIN_ID":            "19763",
+		"HIVE_FORK_HOMESTEAD":      "0",
+		"HIVE_FORK_TANGERINE":      "0",
+		"HIVE_FORK_SPURIOUS":       "0",
+		"HIVE_FORK_BYZANTIUM":      "0",
+		"HIVE_FORK_CONSTANTINOPLE": "0",
+		"HIVE_FORK_PETERSBURG":     "0",
+		"HIVE_FORK_ISTANBUL":       "0",
+		"HIVE_FORK_BERLIN":         "0",
+		"HIVE_SKIP_POW":            "1",
+	}
+)
+
+func main() {
+	suite := hivesim.Suite{
+		Name: "txpool",
+		Description: `This suite checks transaction pool behavior between connected clients.
+For every pair of clients, transactions are sent to the source node through RPC, and the
+test checks which of them reach the sink node. Nodes do not mine, so all transactions
+remain in the pool.`,
+	}
+	suite.Add(hivesim.TestSpec{
+		Name:        "txpool propagation",
+		Description: "This test runs the transaction pool checks for all pairs of eth1 clients.",
+		Run:         propagationTest,
+	})
+	hivesim.MustRunSuite(hivesim.New(), suite)
+}
+
+// propagationTest runs the checks for all client pairs.
+func propagationTest(t *hivesim.T) {
+	dir, err := ioutil.TempDir("", "txpool")
+	if err != nil {
+		t.Fatal("can't create chain directory:", err)
+	}
+	defer os.RemoveAll(dir)
+	sourceFiles, sinkFiles, err := chainFiles(dir)
+	if err != nil {
+		t.Fatal("can't generate chain:", err)
+	}
+
+	clientTypes, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatal("can't get client types:", err)
+	}
+	// The eviction check needs a sink with a small pool.
+	poolSizeSupported := make(map[string]bool)
+	for _, client := range clientTypes {
+		poolSizeSupported[client.Name] = client.HasRole("eth1_txpool_size")
+	}
+	sinkParams := clientParams.Set("HIVE_TXPOOL_SIZE", fmt.Sprint(sinkPoolSize))
+
+	for _, source := range clientTypes {
+		if !source.HasRole("eth1") {
+			continue
+		}
+		source := source.Name
+		t.RunAllClients(hivesim.ClientTestSpec{
+			Role:        "eth1",
+			Name:        fmt.Sprintf("txpool: %s -> CLIENT", source),
+			Description: fmt.Sprintf("Transactions are sent to a %s node and must propagate to the client.", source),
+			Parameters:  sinkParams,
+			Files:       sinkFiles,
+			Run: func(t *hivesim.T, sink *hivesim.Client) {
+				// The sink starts at genesis. Start the source with the chain and make
+				// it dial the sink, so the sink syncs from it.
+				enode, err := sink.EnodeURL()
+				if err != nil {
+					t.Fatal("can't get sink enode URL:", err)
+				}
+				src := t.StartClient(source, clientParams.Set("HIVE_BOOTNODE", enode), hivesim.WithStaticFiles(sourceFiles))
+				p := &pair{
+					t:      t,
+					source: ethclient.NewClient(src.RPC()),
+					sink:   ethclient.NewClient(sink.RPC()),
+				}
+				if err := p.waitSync(); err != nil {
+					t.Fatal("sink did not sync:", err)
+				}
+				p.checkPropagation(accounts[0])
+				p.checkReplacement(accounts[1])
+				p.checkUnderpricedReplacement(accounts[2])
+				p.checkNonceGap(accounts[3])
+				if poolSizeSupported[sink.Type] {
+					p.checkEviction(accounts[4:])
+				}
+			},
+		})
+	}
+}
+
+// pair is a connected source and sink node.
+type pair struct {
+	t      *hivesim.T
+	source *ethclient.Client
+	sink   *ethclient.Client
+}
+
+// checkPropagation sends a transaction to the source and measures the time until
+// it is available on the sink.
+func (p *pair) checkPropagation(key *ecdsa.PrivateKey) {
+	tx := p.send(key, 0, gasPrice)
+	if tx == nil {
+		return
+	}
+	start := time.Now()
+	if err := p.waitPresent(tx); err != nil {
+		p.t.Errorf("propagation: %v", err)
+		return
+	}
+	p.t.Logf("propagation: transaction reached sink after %v", time.Since(start))
+}
+
+// checkReplacement checks that a transaction replaced by one with a higher gas price
+// is evicted from both pools and the replacement propagates.
+func (p *pair) checkReplacement(key *ecdsa.PrivateKey) {
+	tx := p.send(key, 0, gasPrice)
+	if tx == nil {
+		return
+	}
+	if err := p.waitPresent(tx); err != nil {
+		p.t.Errorf("replacement: %v", err)
+		return
+	}
+	replacement := p.send(key, 0, new(big.Int).Mul(gasPrice, big.NewInt(2)))
+	if replacement == nil {
+		return
+	}
+	if err := p.waitPresent(replacement); err != nil {
+		p.t.Errorf("replacement: %v", err)
+	}
+	for _, n := range []struct {
+		name   string
+		client *ethclient.Client
+	}{{"source", p.source}, {"sink", p.sink}} {
+		if err := waitTx(n.client, tx.Hash(), false, propagationTimeout); err != nil {
+			p.t.Errorf("replacement: replaced transaction not evicted from %s: %v", n.name, err)
+		}
+	}
+}
+
+// checkUnderpricedReplacement checks that the source rejects a replacement transaction
+// with a gas price bump below 10%, and that the original transaction stays in the pool
+// of the sink.
+func (p *pair) checkUnderpricedReplacement(key *ecdsa.PrivateKey) {
+	tx := p.send(key, 0, gasPrice)
+	if tx == nil {
+		return
+	}
+	if err := p.waitPresent(tx); err != nil {
+		p.t.Errorf("underpriced replacement: %v", err)
+		return
+	}
+	price := new(big.Int).Add(gasPrice, big.NewInt(1))
+	replacement := signTx(key, 0, price)
+	if err := p.source.SendTransaction(context.Background(), replacement); err == nil {
+		p.t.Errorf("underpriced replacement: source accepted replacement with gas price %v, original %v", price, gasPrice)
+	}
+	time.Sleep(queuedWait)
+	if err := waitTx(p.sink, tx.Hash(), true, 0); err != nil {
+		p.t.Errorf("underpriced replacement: original transaction missing on sink: %v", err)
+	}
+	if err := waitTx(p.sink, replacement.Hash(), false, 0); err != nil {
+		p.t.Errorf("underpriced replacement: %v", err)
+	}
+}
+
+// checkNonceGap checks that a transaction with a nonce gap is queued on the source
+// and not propagated, and that it propagates when the gap is filled.
+func (p *pair) checkNonceGap(key *ecdsa.PrivateKey) {
+	queued := p.send(key, 1, gasPrice)
+	if queued == nil {
+		return
+	}
+	time.Sleep(queuedWait)
+	if err := waitTx(p.sink, queued.Hash(), false, 0); err != nil {
+		p.t.Errorf("nonce gap: queued transaction was propagated: %v", err)
+	}
+	filler := p.send(key, 0, gasPrice)
+	if filler == nil {
+		return
+	}
+	for _, tx := range []*types.Transaction{filler, queued} {
+		if err := p.waitPresent(tx); err != nil {
+			p.t.Errorf("nonce gap: after filling gap: %v", err)
+		}
+	}
+}
+
+// checkEviction checks that the sink evicts the cheapest transactions when its pool
+// is full. Every transaction is sent from its own account, with a gas price above
+// those of the other checks. They are sent in order of increasing gas price, so the
+// sink fills its pool with the cheapest transactions first. The source does not evict
+// transactions submitted through RPC, so all of them propagate. Once all transactions
+// have arrived, the sink must hold the sinkPoolSize most expensive ones.
+func (p *pair) checkEviction(keys []*ecdsa.PrivateKey) {
+	txs := make([]*types.Transaction, len(keys))
+	for i, key := range keys {
+		price := new(big.Int).Mul(gasPrice, big.NewInt(int64(3+i)))
+		if txs[i] = p.send(key, 0, price); txs[i] == nil {
+			return
+		}
+	}
+	evicted, kept := txs[:len(txs)-sinkPoolSize], txs[len(txs)-sinkPoolSize:]
+	for _, tx := range kept {
+		if err := p.waitPresent(tx); err != nil {
+			p.t.Errorf("eviction: %v", err)
+		}
+	}
+	for _, tx := range evicted {
+		if err := waitTx(p.sink, tx.Hash(), false, 0); err != nil {
+			p.t.Errorf("eviction: cheap transaction not evicted from sink: %v", err)
+		}
+	}
+}
+
+// send signs a value transfer and sends it to the source node. It returns nil if the
+// source rejects the transaction.
+func (p *pair) send(key *ecdsa.PrivateKey, nonce uint64, price *big.Int) *types.Transaction {
+	tx := signTx(key, nonce, price)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.source.SendTransaction(ctx, tx); err != nil {
+		p.t.Errorf("source rejected transaction (nonce %d, gas price %v): %v", nonce, price, err)
+		return nil
+	}
+	return tx
+}
+
+// waitPresent waits for the transaction to appear on the sink.
+func (p *pair) waitPresent(tx *types.Transaction) error {
+	return waitTx(p.sink, tx.Hash(), true, propagationTimeout)
+}
+
+// waitSync waits for the sink to import the source chain.
+func (p *pair) waitSync() error {
+	deadline := time.Now().Add(syncTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		head, err := p.sink.HeaderByNumber(ctx, nil)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("can't query chain head: %v", err)
+		}
+		if head.Number.Uint64() >= sourceChainLength {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout, head is %d, want %d", head.Number, sourceChainLength)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// waitTx waits until the presence of a transaction in the client matches want. With a
+// zero timeout, the presence is checked once.
+func waitTx(client *ethclient.Client, hash common.Hash, want bool, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, _, err := client.TransactionByHash(ctx, hash)
+		cancel()
+		switch {
+		case err == ethereum.NotFound:
+			if !want {
+				return nil
+			}
+		case err != nil:
+			return fmt.Errorf("can't get transaction %x: %v", hash, err)
+		case want:
+			return nil
+		}
+		if !time.Now().Before(deadline) {
+			if want {
+				return fmt.Errorf("transaction %x not found after %v", hash, timeout)
+			}
+			return fmt.Errorf("transaction %x still present after %v", hash, timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// signTx creates a value transfer to the sender's own address.
+func signTx(key *ecdsa.PrivateKey, nonce uint64, price *big.Int) *types.Transaction {
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	tx := types.NewTransaction(nonce, addr, big.NewInt(1), params.TxGas, price, nil)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(chainConfig.ChainID), key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
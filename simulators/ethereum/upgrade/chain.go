@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// chainLength is the number of blocks imported by the old client version.
+const chainLength = 16
+
+// chainConfig is the chain configuration of the generated chain. It must match the
+// HIVE_FORK_* parameters given to clients.
+var chainConfig = &params.ChainConfig{
+	ChainID:             big.NewInt(19763),
+	HomesteadBlock:      big.NewInt(0),
+	EIP150Block:         big.NewInt(0),
+	EIP155Block:         big.NewInt(0),
+	EIP158Block:         big.NewInt(0),
+	ByzantiumBlock:      big.NewInt(0),
+	ConstantinopleBlock: big.NewInt(0),
+	PetersburgBlock:     big.NewInt(0),
+	IstanbulBlock:       big.NewInt(0),
+	BerlinBlock:         big.NewInt(0),
+	Ethash:              new(params.EthashConfig),
+}
+
+var (
+	senderKey, _ = crypto.ToECDSA(crypto.Keccak256([]byte("hive upgrade")))
+	sender       = crypto.PubkeyToAddress(senderKey.PublicKey)
+
+	// storageInitCode stores 42 in slot zero and deploys a single STOP instruction.
+	storageInitCode = hexutil.MustDecode("0x602a60005560016000f3")
+)
+
+// testChain describes the generated chain.
+type testChain struct {
+	files    map[string]string // client input files of the old version
+	genesis  map[string]string // genesis file only, for the new version
+	accounts []common.Address  // accounts touched by the chain
+	contract common.Address    // contract with non-empty storage
+	txs      []common.Hash     // all transactions in the chain
+}
+
+// makeChain generates the test chain and writes the client input files to dir.
+//
+// Every block contains a value transfer to a fresh account. The first block also
+// deploys a contract with storage. Blocks are sealed by the fake ethash engine and
+// clients must be started with HIVE_SKIP_POW.
+func makeChain(dir string) (*testChain, error) {
+	gspec := &core.Genesis{
+		Config:     chainConfig,
+		Difficulty: big.NewInt(0x20000),
+		GasLimit:   0x2fefd8,
+		ExtraData:  []byte("hive upgrade"),
+		Alloc: core.GenesisAlloc{
+			sender: {Balance: new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))},
+		},
+	}
+	db := rawdb.NewMemoryDatabase()
+	genesis := gspec.MustCommit(db)
+	signer := types.LatestSigner(chainConfig)
+
+	chain := &testChain{
+		accounts: []common.Address{sender},
+		contract: crypto.CreateAddress(sender, 0),
+	}
+	blocks, _ := core.GenerateChain(chainConfig, genesis, ethash.NewFaker(), db, chainLength, func(i int, b *core.BlockGen) {
+		if i == 0 {
+			tx := types.NewContractCreation(b.TxNonce(sender), new(big.Int), 100000, big.NewInt(params.GWei), storageInitCode)
+			tx = signTx(tx, signer)
+			b.AddTx(tx)
+			chain.txs = append(chain.txs, tx.Hash())
+		}
+		to := common.BigToAddress(big.NewInt(int64(0x1000 + i)))
+		tx := types.NewTransaction(b.TxNonce(sender), to, big.NewInt(int64(i+1)), params.TxGas, big.NewInt(params.GWei), nil)
+		tx = signTx(tx, signer)
+		b.AddTx(tx)
+		chain.txs = append(chain.txs, tx.Hash())
+		chain.accounts = append(chain.accounts, to)
+	})
+
+	genesisFile := filepath.Join(dir, "genesis.json")
+	genesisJSON, err := json.Marshal(gspec)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(genesisFile, genesisJSON, 0644); err != nil {
+		return nil, err
+	}
+	chainFile := filepath.Join(dir, "chain.rlp")
+	if err := writeBlocks(chainFile, blocks); err != nil {
+		return nil, err
+	}
+	chain.files = map[string]string{"/genesis.json": genesisFile, "/chain.rlp": chainFile}
+	chain.genesis = map[string]string{"/genesis.json": genesisFile}
+	return chain, nil
+}
+
+func signTx(tx *types.Transaction, signer types.Signer) *types.Transaction {
+	signed, err := types.SignTx(tx, signer, senderKey)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// writeBlocks writes RLP-encoded blocks to the given file.
+func writeBlocks(file string, blocks []*types.Block) error {
+	out, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	for _, block := range blocks {
+		if err := rlp.Encode(out, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/hive/hivesim"
+)
+
+var (
+	// the amount of time the check of a client's state may take
+	rpcTimeout = 30 * time.Second
+
+	clientParams = hivesim.Params{
+		"HIVE_NETWORK_ID":          "19763",
+		"HIVE_CHAIN_ID":            "19763",
+		"HIVE_FORK_HOMESTEAD":      "0",
+		"HIVE_FORK_TANGERINE":      "0",
+		"HIVE_FORK_SPURIOUS":       "0",
+		"HIVE_FORK_BYZANTIUM":      "0",
+		"HIVE_FORK_CONSTANTINOPLE": "0",
+		"HIVE_FORK_PETERSBURG":     "0",
+		"HIVE_FORK_ISTANBUL":       "0",
+		"HIVE_FORK_BERLIN":         "0",
+		"HIVE_SKIP_POW":            "1",
+	}
+)
+
+// datadirs contains the database location of clients which support this test. The
+// directory is mounted as a volume shared by the old and new client versions.
+var datadirs = map[string]string{
+	"go-ethereum": "/root/.ethereum",
+}
+
+func main() {
+	suite := hivesim.Suite{
+		Name: "upgrade",
+		Description: `This suite checks that clients can be upgraded without losing data.
+An old client version imports a test chain into a persistent data directory. It is
+then stopped, and the new version is started on the same data directory. The chain
+head, state and receipts must be unchanged after the upgrade.`,
+	}
+	suite.Add(hivesim.TestSpec{
+		Name:        "client upgrade",
+		Description: "This test runs the upgrade check for all pairs of versions of each client.",
+		Run:         upgradeTest,
+	})
+	hivesim.MustRunSuite(hivesim.New(), suite)
+}
+
+// upgradeTest runs the upgrade check for all version pairs.
+func upgradeTest(t *hivesim.T) {
+	dir, err := ioutil.TempDir("", "upgrade")
+	if err != nil {
+		t.Fatal("can't create chain directory:", err)
+	}
+	defer os.RemoveAll(dir)
+	chain, err := makeChain(dir)
+	if err != nil {
+		t.Fatal("can't generate chain:", err)
+	}
+
+	clientTypes, err := t.Sim.ClientTypes()
+	if err != nil {
+		t.Fatal("can't get client types:", err)
+	}
+	// Group client versions by client name. The versions are the branches given
+	// on the hive command line, e.g. --client go-ethereum_v1.10.0,go-ethereum.
+	versions := make(map[string][]string)
+	var names []string
+	for _, client := range clientTypes {
+		if !client.HasRole("eth1") {
+			continue
+		}
+		name := clientName(client.Name)
+		if _, ok := datadirs[name]; !ok {
+			t.Logf("skipping %s: data directory unknown", client.Name)
+			continue
+		}
+		if versions[name] == nil {
+			names = append(names, name)
+		}
+		versions[name] = append(versions[name], client.Name)
+	}
+
+	// Run all ordered pairs of versions. Pairs of the same version check that
+	// the client can be restarted on its own database.
+	var volumes int
+	for _, name := range names {
+		for _, from := range versions[name] {
+			for _, to := range versions[name] {
+				volumes++
+				u := upgrade{
+					chain:  chain,
+					from:   from,
+					to:     to,
+					volume: fmt.Sprintf("datadir-%d", volumes),
+					path:   datadirs[name],
+				}
+				t.Run(hivesim.TestSpec{
+					Name:        fmt.Sprintf("upgrade (%s -> %s)", from, to),
+					Description: fmt.Sprintf("This test imports the chain with %s, then restarts the node as %s on the same data directory.", from, to),
+					Run:         u.run,
+				})
+			}
+		}
+	}
+}
+
+// clientName strips the branch from a client name.
+func clientName(name string) string {
+	if i := strings.LastIndex(name, "_"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// upgrade is a single upgrade check.
+type upgrade struct {
+	chain    *testChain
+	from, to string
+	volume   string // name of the data directory volume
+	path     string // data directory in the client container
+}
+
+func (u *upgrade) run(t *hivesim.T) {
+	datadir := hivesim.WithVolume(u.volume, u.path)
+
+	// Import the chain with the old version.
+	old := t.StartClient(u.from, clientParams, datadir, hivesim.WithStaticFiles(u.chain.files))
+	before, err := takeSnapshot(old, u.chain)
+	if err != nil {
+		t.Fatalf("can't read state of %s: %v", u.from, err)
+	}
+	if n := before.head.Number.Uint64(); n != chainLength {
+		t.Fatalf("%s did not import the chain: head is block %d, want %d", u.from, n, chainLength)
+	}
+	if err := t.Sim.StopClient(t.SuiteID, t.TestID, old.Container); err != nil {
+		t.Fatalf("can't stop %s: %v", u.from, err)
+	}
+
+	// Start the new version without the chain. All data must come from the volume.
+	upgraded := t.StartClient(u.to, clientParams, datadir, hivesim.WithStaticFiles(u.chain.genesis))
+	after, err := takeSnapshot(upgraded, u.chain)
+	if err != nil {
+		t.Fatalf("can't read state of %s: %v", u.to, err)
+	}
+	for _, diff := range before.compare(after) {
+		t.Errorf("after upgrade to %s: %s", u.to, diff)
+	}
+}
+
+// snapshot is the chain data of a client which must survive the upgrade.
+type snapshot struct {
+	head     *types.Header
+	balances map[common.Address]*big.Int
+	nonces   map[common.Address]uint64
+	code     []byte
+	storage  []byte
+	receipts map[common.Hash]*types.Receipt
+}
+
+// takeSnapshot reads the head block, the state of all accounts touched by the chain
+// and all receipts from the client.
+func takeSnapshot(c *hivesim.Client, chain *testChain) (*snapshot, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rpcTimeout)
+	defer cancel()
+
+	client := ethclient.NewClient(c.RPC())
+	s := &snapshot{
+		balances: make(map[common.Address]*big.Int),
+		nonces:   make(map[common.Address]uint64),
+		receipts: make(map[common.Hash]*types.Receipt),
+	}
+	var err error
+	if s.head, err = client.HeaderByNumber(ctx, nil); err != nil {
+		return nil, fmt.Errorf("head block: %v", err)
+	}
+	// Read state at the head block, so the snapshots are comparable even
+	// if the client modifies its pending state.
+	number := s.head.Number
+	for _, addr := range chain.accounts {
+		if s.balances[addr], err = client.BalanceAt(ctx, addr, number); err != nil {
+			return nil, fmt.Errorf("balance of %x: %v", addr, err)
+		}
+		if s.nonces[addr], err = client.NonceAt(ctx, addr, number); err != nil {
+			return nil, fmt.Errorf("nonce of %x: %v", addr, err)
+		}
+	}
+	if s.code, err = client.CodeAt(ctx, chain.contract, number); err != nil {
+		return nil, fmt.Errorf("contract code: %v", err)
+	}
+	if s.storage, err = client.StorageAt(ctx, chain.contract, common.Hash{}, number); err != nil {
+		return nil, fmt.Errorf("contract storage: %v", err)
+	}
+	for _, hash := range chain.txs {
+		if s.receipts[hash], err = client.TransactionReceipt(ctx, hash); err != nil {
+			return nil, fmt.Errorf("receipt of %x: %v", hash, err)
+		}
+	}
+	return s, nil
+}
+
+// compare returns the differences between two snapshots.
+func (s *snapshot) compare(other *snapshot) []string {
+	var diffs []string
+	if s.head.Hash() != other.head.Hash() {
+		diffs = append(diffs, fmt.Sprintf("head is block %d (%x), want %d (%x)", other.head.Number, other.head.Hash(), s.head.Number, s.head.Hash()))
+	}
+	for addr, balance := range s.balances {
+		if other.balances[addr].Cmp(balance) != 0 {
+			diffs = append(diffs, fmt.Sprintf("balance of %x is %v, want %v", addr, other.balances[addr], balance))
+		}
+		if other.nonces[addr] != s.nonces[addr] {
+			diffs = append(diffs, fmt.Sprintf("nonce of %x is %d, want %d", addr, other.nonces[addr], s.nonces[addr]))
+		}
+	}
+	if !bytes.Equal(s.code, other.code) {
+		diffs = append(diffs, fmt.Sprintf("contract code is %x, want %x", other.code, s.code))
+	}
+	if !bytes.Equal(s.storage, other.storage) {
+		diffs = append(diffs, fmt.Sprintf("contract storage is %x, want %x", other.storage, s.storage))
+	}
+	for hash, want := range s.receipts {
+		got := other.receipts[hash]
+		switch {
+		case got.BlockHash != want.BlockHash:
+			diffs = append(diffs, fmt.Sprintf("receipt of %x has block hash %x, want %x", hash, got.BlockHash, want.BlockHash))
+		case got.Status != want.Status:
+			diffs = append(diffs, fmt.Sprintf("receipt of %x has status %d, want %d", hash, got.Status, want.Status))
+		case got.CumulativeGasUsed != want.CumulativeGasUsed:
+			diffs = append(diffs, fmt.Sprintf("receipt of %x has cumulative gas %d, want %d", hash, got.CumulativeGasUsed, want.CumulativeGasUsed))
+		case got.ContractAddress != want.ContractAddress:
+			diffs = append(diffs, fmt.Sprintf("receipt of %x has contract address %x, want %x", hash, got.ContractAddress, want.ContractAddress))
+		case got.Bloom != want.Bloom:
+			diffs = append(diffs, fmt.Sprintf("receipt of %x has wrong logs bloom", hash))
+		}
+	}
+	return diffs
+}